@@ -0,0 +1,296 @@
+package mongodb
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/benpate/data"
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkResult reports how many documents were affected by a bulk operation.
+type BulkResult struct {
+	InsertedCount int64 // InsertedCount is the number of documents that were inserted
+	MatchedCount  int64 // MatchedCount is the number of existing documents that were matched for replacement
+	ModifiedCount int64 // ModifiedCount is the number of existing documents that were actually changed
+}
+
+// BulkFailure describes a single object that could not be written by a bulk
+// operation.  A bulk operation error carries a []BulkFailure in its details,
+// which can be retrieved with GetBulkFailures.
+type BulkFailure struct {
+	Index   int    `json:"index"`   // Index is the position of the object in the slice that was passed to the bulk operation
+	ID      string `json:"id"`      // ID is the ID of the object that failed
+	Code    int    `json:"code"`    // Code is the mongodb error code, or zero if the object failed before it was sent to the server
+	Message string `json:"message"` // Message describes why the object failed
+}
+
+// GetBulkFailures returns the per-object failures reported by a bulk
+// operation error, or nil if the error does not include any.
+func GetBulkFailures(err error) []BulkFailure {
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		for _, detail := range derp.Details(err) {
+			if failures, ok := detail.([]BulkFailure); ok {
+				return failures
+			}
+		}
+	}
+
+	return nil
+}
+
+// InsertMany inserts a group of new objects into the database in a single
+// round trip, stamping each object's journal with SetCreated.
+func (c Collection) InsertMany(objects []data.Object, note string, options ...option.Option) (BulkResult, error) {
+
+	const location = "data-mongo.Collection.InsertMany"
+
+	defer c.reportIfSlow(location, startTimer(), len(objects))
+
+	models := make([]mongo.WriteModel, len(objects))
+
+	for index, object := range objects {
+		models[index] = c.insertModel(object, note)
+	}
+
+	return c.bulkWrite(location, objects, models, nil, options...)
+}
+
+// SaveMany inserts/updates a group of objects in the database in a single
+// round trip.  Each object is stamped and written in the same way as Save,
+// including the revision check when it is enabled.  Stale objects are reported
+// as a 409 error, whose []BulkFailure is found by a second lookup after the
+// write; an object that someone else changes in between is reported as well.
+func (c Collection) SaveMany(objects []data.Object, note string, options ...option.Option) (BulkResult, error) {
+
+	const location = "data-mongo.Collection.SaveMany"
+
+	defer c.reportIfSlow(location, startTimer(), len(objects))
+
+	// Build every filter before stamping any objects, so that nothing is
+	// modified if any object cannot be written.
+	filters, failures := c.bulkFilters(objects)
+
+	if len(failures) > 0 {
+		return BulkResult{}, derp.BadRequest(location, "Preparing objects", failures)
+	}
+
+	models := make([]mongo.WriteModel, len(objects))
+	replaced := make([]int, 0, len(objects))
+
+	for index, object := range objects {
+
+		if object.IsNew() {
+			models[index] = c.insertModel(object, note)
+			continue
+		}
+
+		object.SetUpdated(note)
+		models[index] = mongo.NewReplaceOneModel().SetFilter(filters[index]).SetReplacement(object)
+		replaced = append(replaced, index)
+	}
+
+	return c.bulkWrite(location, objects, models, replaced, options...)
+}
+
+// DeleteMany virtually deletes a group of objects in a single round trip.
+// Each object is stamped and written in the same way as Delete.  Stale objects
+// are reported in the same way as SaveMany.
+func (c Collection) DeleteMany(objects []data.Object, note string, options ...option.Option) (BulkResult, error) {
+
+	const location = "data-mongo.Collection.DeleteMany"
+
+	defer c.reportIfSlow(location, startTimer(), len(objects))
+
+	filters, failures := c.bulkFilters(objects)
+
+	// Unsaved objects cannot be deleted
+	for index, object := range objects {
+		if object.IsNew() {
+			failures = append(failures, BulkFailure{Index: index, ID: object.ID(), Message: "Deleting unsaved object"})
+		}
+	}
+
+	if len(failures) > 0 {
+		return BulkResult{}, derp.BadRequest(location, "Preparing objects", failures)
+	}
+
+	models := make([]mongo.WriteModel, len(objects))
+	replaced := make([]int, len(objects))
+
+	for index, object := range objects {
+		object.SetDeleted(note)
+		object.SetUpdated(note)
+		models[index] = mongo.NewReplaceOneModel().SetFilter(filters[index]).SetReplacement(object)
+		replaced[index] = index
+	}
+
+	return c.bulkWrite(location, objects, models, replaced, options...)
+}
+
+// insertModel stamps a new object in the same way as Save, and returns the
+// model that inserts it.
+func (c Collection) insertModel(object data.Object, note string) mongo.WriteModel {
+	object.SetUpdated(note)
+	object.SetCreated(note)
+	return mongo.NewInsertOneModel().SetDocument(object)
+}
+
+// bulkFilters returns the filter that matches the stored document for each
+// existing object (using the loaded revision when the revision check is
+// enabled), along with a failure for each object whose filter cannot be built.
+// New objects have no filter.
+func (c Collection) bulkFilters(objects []data.Object) ([]bson.M, []BulkFailure) {
+
+	filters := make([]bson.M, len(objects))
+	failures := make([]BulkFailure, 0)
+
+	for index, object := range objects {
+
+		if object.IsNew() {
+			continue
+		}
+
+		filter, err := c.idFilter(object)
+
+		if err == nil {
+			err = c.addRevision(filter, object.ETag())
+		}
+
+		if err != nil {
+			failures = append(failures, BulkFailure{Index: index, ID: object.ID(), Message: err.Error()})
+			continue
+		}
+
+		filters[index] = filter
+	}
+
+	return filters, failures
+}
+
+// bulkWrite sends a group of write models to the server, and translates the
+// result.  Write errors are reported as a 400 with a []BulkFailure in the
+// error details.  replaced lists the indexes of the replacement models.  When
+// the revision check is enabled, replacements that did not match a document
+// are reported as a 409 Conflict, also with a []BulkFailure in the details.
+func (c Collection) bulkWrite(location string, objects []data.Object, models []mongo.WriteModel, replaced []int, options ...option.Option) (BulkResult, error) {
+
+	if len(models) == 0 {
+		return BulkResult{}, nil
+	}
+
+	result, err := c.collection.BulkWrite(c.context, models, bulkWriteOptions(options...))
+
+	bulkResult := BulkResult{}
+
+	if result != nil {
+		bulkResult.InsertedCount = result.InsertedCount
+		bulkResult.MatchedCount = result.MatchedCount
+		bulkResult.ModifiedCount = result.ModifiedCount
+	}
+
+	if err != nil {
+
+		exception := mongo.BulkWriteException{}
+
+		if errors.As(err, &exception) && len(exception.WriteErrors) > 0 {
+
+			failures := make([]BulkFailure, 0, len(exception.WriteErrors))
+
+			for _, writeError := range exception.WriteErrors {
+				failure := BulkFailure{Index: writeError.Index, Code: writeError.Code, Message: writeError.Message}
+				if writeError.Index >= 0 && writeError.Index < len(objects) {
+					failure.ID = objects[writeError.Index].ID()
+				}
+				failures = append(failures, failure)
+			}
+
+			return bulkResult, derp.Wrap(err, location, "Writing objects", failures, derp.WithBadRequest())
+		}
+
+		return bulkResult, derp.Wrap(err, location, "Writing objects", len(objects), derp.WithCode(http.StatusInternalServerError))
+	}
+
+	if c.settings.revisionCheck && bulkResult.MatchedCount < int64(len(replaced)) {
+
+		failures, err := c.bulkConflicts(objects, replaced)
+
+		if err != nil {
+			return bulkResult, derp.Wrap(err, location, "Finding conflicting objects", len(replaced), bulkResult.MatchedCount, derp.WithCode(http.StatusConflict))
+		}
+
+		return bulkResult, derp.BadRequest(location, "Objects were modified or removed since they were loaded", failures, derp.WithCode(http.StatusConflict))
+	}
+
+	return bulkResult, nil
+}
+
+// bulkConflicts returns a failure for each replaced object whose write did not
+// match a document.  The server only reports how many replacements matched, so
+// each object is looked up by its `_id` and the journal fields that its
+// replacement stamped (revision, dates and note).  Objects that cannot be found
+// with them were modified or removed by someone else.  The lookup runs after
+// the write, so a document that is changed again in between is also reported.
+func (c Collection) bulkConflicts(objects []data.Object, replaced []int) ([]BulkFailure, error) {
+
+	const location = "data-mongo.Collection.bulkConflicts"
+
+	criteria := make(bson.A, 0, len(replaced))
+	ids := make([]bson.RawValue, len(replaced))
+
+	for position, index := range replaced {
+
+		filter, err := c.idFilter(objects[index])
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Decoding object ID", objects[index].ID())
+		}
+
+		ids[position] = rawValue(filter["_id"])
+
+		fields, err := documentFields(objects[index])
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Marshalling object", objects[index].ID())
+		}
+
+		for _, field := range []string{journalRevision, journalUpdateDate, journalDeleteDate, journalNote} {
+			filter[field] = fields[field]
+		}
+
+		criteria = append(criteria, filter)
+	}
+
+	cursor, err := c.collection.Find(c.context, bson.M{"$or": criteria}, mongoOptions.Find().SetProjection(bson.M{"_id": 1}))
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Listing written objects", derp.WithCode(queryErrorCode(err)))
+	}
+
+	written := make([]bson.Raw, 0)
+
+	if err := cursor.All(c.context, &written); err != nil {
+		return nil, derp.Wrap(err, location, "Reading written objects", derp.WithCode(queryErrorCode(err)))
+	}
+
+	failures := make([]BulkFailure, 0, len(replaced))
+
+	for position, index := range replaced {
+
+		matched := slices.ContainsFunc(written, func(document bson.Raw) bool {
+			return document.Lookup("_id").Equal(ids[position])
+		})
+
+		if !matched {
+			failures = append(failures, BulkFailure{Index: index, ID: objects[index].ID(), Message: "Object was modified or removed since it was loaded"})
+		}
+	}
+
+	return failures, nil
+}
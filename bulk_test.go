@@ -0,0 +1,300 @@
+package mongodb
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/benpate/data"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peopleObjects converts a list of people into the []data.Object that the
+// bulk operations expect.
+func peopleObjects(people ...*testPerson) []data.Object {
+	result := make([]data.Object, len(people))
+	for index, person := range people {
+		result[index] = person
+	}
+	return result
+}
+
+/******************************************
+ * GetBulkFailures()
+ ******************************************/
+
+func TestGetBulkFailures(t *testing.T) {
+
+	failures := []BulkFailure{{Index: 2, ID: "abc", Code: 11000, Message: "duplicate key"}}
+
+	// Failures are found in the details of the outermost error...
+	err := derp.BadRequest("test", "bulk", failures)
+	assert.Equal(t, failures, GetBulkFailures(err))
+
+	// ...or anywhere further down the chain.
+	wrapped := derp.Wrap(err, "test.outer", "wrapping")
+	assert.Equal(t, failures, GetBulkFailures(wrapped))
+}
+
+func TestGetBulkFailures_None(t *testing.T) {
+	assert.Nil(t, GetBulkFailures(nil))
+	assert.Nil(t, GetBulkFailures(errors.New("plain error")))
+	assert.Nil(t, GetBulkFailures(derp.BadRequest("test", "no failures", "detail")))
+}
+
+/******************************************
+ * InsertMany()
+ ******************************************/
+
+func TestCollection_InsertMany(t *testing.T) {
+
+	collection := getTestCollection(t)
+	people := peopleObjects(
+		newTestPerson("John Connor", 20),
+		newTestPerson("Sarah Connor", 45),
+		newTestPerson("Kyle Reese", 30),
+	)
+
+	result, err := collection.InsertMany(people, "import")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.InsertedCount)
+
+	// Every object is stamped with its own journal.
+	for _, object := range people {
+		assert.False(t, object.IsNew())
+		assert.Equal(t, "import", object.(*testPerson).Note)
+	}
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+// An empty slice is a no-op.
+func TestCollection_InsertMany_Empty(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	result, err := collection.InsertMany(nil, "import")
+	require.NoError(t, err)
+	assert.Equal(t, BulkResult{}, result)
+}
+
+// In ordered mode (the default) the write stops at the first failure, which is
+// reported by index.
+func TestCollection_InsertMany_Ordered(t *testing.T) {
+
+	collection := getTestCollection(t)
+	duplicate := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, duplicate)
+
+	copied := *duplicate
+	copied.CreateDate = 0
+
+	people := peopleObjects(
+		newTestPerson("Sarah Connor", 45),
+		&copied, // duplicate _id
+		newTestPerson("Kyle Reese", 30),
+	)
+
+	result, err := collection.InsertMany(people, "import")
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+	assert.Equal(t, int64(1), result.InsertedCount)
+
+	failures := GetBulkFailures(err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, 1, failures[0].Index)
+	assert.Equal(t, duplicate.ID(), failures[0].ID)
+	assert.Equal(t, 11000, failures[0].Code) // duplicate key
+	assert.NotEmpty(t, failures[0].Message)
+}
+
+// In unordered mode the write continues past failures.
+func TestCollection_InsertMany_Unordered(t *testing.T) {
+
+	collection := getTestCollection(t)
+	duplicate := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, duplicate)
+
+	copied := *duplicate
+	copied.CreateDate = 0
+
+	people := peopleObjects(
+		newTestPerson("Sarah Connor", 45),
+		&copied, // duplicate _id
+		newTestPerson("Kyle Reese", 30),
+	)
+
+	result, err := collection.InsertMany(people, "import", Unordered())
+	require.Error(t, err)
+	assert.Equal(t, int64(2), result.InsertedCount)
+
+	failures := GetBulkFailures(err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, 1, failures[0].Index)
+}
+
+/******************************************
+ * SaveMany()
+ ******************************************/
+
+// SaveMany inserts new objects and replaces existing ones in a single call.
+func TestCollection_SaveMany(t *testing.T) {
+
+	collection := getTestCollection(t)
+	existing := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, existing)
+
+	existing.Age = 21
+	people := peopleObjects(existing, newTestPerson("Sarah Connor", 45))
+
+	result, err := collection.SaveMany(people, "sync")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.InsertedCount)
+	assert.Equal(t, int64(1), result.MatchedCount)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", existing.PersonID), &loaded))
+	assert.Equal(t, 21, loaded.Age)
+	assert.Equal(t, "sync", loaded.Note)
+}
+
+// Objects whose IDs cannot be decoded are reported before anything is written.
+func TestCollection_SaveMany_InvalidID(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	bad := &badIDObject{}
+	bad.markOld()
+
+	person := newTestPerson("Sarah Connor", 45)
+	objects := []data.Object{person, bad}
+
+	_, err := collection.SaveMany(objects, "sync")
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+
+	failures := GetBulkFailures(err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, 1, failures[0].Index)
+	assert.Equal(t, bad.ID(), failures[0].ID)
+
+	// Nothing was stamped or written.
+	assert.True(t, person.IsNew())
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+// With the revision check enabled, stale objects are reported as a conflict.
+func TestCollection_SaveMany_RevisionConflict(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	first := newTestPerson("John Connor", 20)
+	second := newTestPerson("Sarah Connor", 45)
+	seedPeople(t, collection, first, second)
+
+	stale := *second
+	require.NoError(t, collection.Save(second, "elsewhere"))
+
+	result, err := collection.SaveMany(peopleObjects(first, &stale), "sync")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+	assert.Equal(t, int64(1), result.MatchedCount)
+
+	// Only the stale object is reported
+	failures := GetBulkFailures(err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, 1, failures[0].Index)
+	assert.Equal(t, second.ID(), failures[0].ID)
+}
+
+/******************************************
+ * DeleteMany()
+ ******************************************/
+
+func TestCollection_DeleteMany(t *testing.T) {
+
+	collection := getTestCollection(t)
+	first := newTestPerson("John Connor", 20)
+	second := newTestPerson("Sarah Connor", 45)
+	seedPeople(t, collection, first, second, newTestPerson("Kyle Reese", 30))
+
+	result, err := collection.DeleteMany(peopleObjects(first, second), "cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.ModifiedCount)
+	assert.True(t, first.IsDeleted())
+	assert.True(t, second.IsDeleted())
+
+	// The documents are virtually deleted, not removed.
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	count, err = collection.With(WithoutDeleted()).Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+// Unsaved objects cannot be deleted, and are reported by index.
+func TestCollection_DeleteMany_NewObject(t *testing.T) {
+
+	collection := getTestCollection(t)
+	saved := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, saved)
+
+	_, err := collection.DeleteMany(peopleObjects(saved, newTestPerson("Never Saved", 99)), "cleanup")
+	require.Error(t, err)
+
+	failures := GetBulkFailures(err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, 1, failures[0].Index)
+	assert.False(t, saved.IsDeleted())
+}
+
+// With the revision check enabled, stale and removed objects are reported by
+// index, even when a stale copy's new revision matches the stored one.
+func TestCollection_DeleteMany_RevisionConflict(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	first := newTestPerson("John Connor", 20)
+	second := newTestPerson("Sarah Connor", 45)
+	third := newTestPerson("Kyle Reese", 30)
+	seedPeople(t, collection, first, second, third)
+
+	stale := *second
+	require.NoError(t, collection.Save(second, "elsewhere"))
+	require.NoError(t, collection.Save(second, "elsewhere"))
+	require.NoError(t, collection.HardDelete(exp.Equal("_id", third.PersonID)))
+
+	result, err := collection.DeleteMany(peopleObjects(first, &stale, third), "cleanup")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+	assert.Equal(t, int64(1), result.MatchedCount)
+
+	failures := GetBulkFailures(err)
+	require.Len(t, failures, 2)
+	assert.Equal(t, 1, failures[0].Index)
+	assert.Equal(t, second.ID(), failures[0].ID)
+	assert.Equal(t, 2, failures[1].Index)
+	assert.Equal(t, third.ID(), failures[1].ID)
+}
+
+// With the revision check enabled, deleting fresh copies succeeds.
+func TestCollection_DeleteMany_RevisionCheck(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	first := newTestPerson("John Connor", 20)
+	second := newTestPerson("Sarah Connor", 45)
+	seedPeople(t, collection, first, second)
+
+	result, err := collection.DeleteMany(peopleObjects(first, second), "cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.MatchedCount)
+}
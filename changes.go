@@ -0,0 +1,183 @@
+package mongodb
+
+import (
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Changes is a set of field-level modifications that is applied to documents
+// in place, instead of replacing them entirely.  It is keyed by mongodb update
+// operator ($set, $unset, $inc, $push...), and each operator holds the fields
+// it modifies.  The zero value is an empty change set, and every method returns
+// the updated set so that calls can be chained:
+//
+//	changes := mongodb.Changes{}.Set("name", "Sarah").Unset("nickname").Inc("logins", 1)
+type Changes map[string]bson.M
+
+// Set assigns a new value to a field.
+func (changes Changes) Set(field string, value any) Changes {
+	return changes.add("$set", field, value)
+}
+
+// SetAll assigns new values to every field in the map.
+func (changes Changes) SetAll(fields map[string]any) Changes {
+	for field, value := range fields {
+		changes = changes.Set(field, value)
+	}
+	return changes
+}
+
+// Unset removes a field from the document.
+func (changes Changes) Unset(field string) Changes {
+	return changes.add("$unset", field, "")
+}
+
+// Inc increments a numeric field by delta, which may be negative.  A missing
+// field is created with the value of delta.
+func (changes Changes) Inc(field string, delta any) Changes {
+	return changes.add("$inc", field, delta)
+}
+
+// Min replaces a field with the value, only if the value is less than the
+// current value (or the field is missing).
+func (changes Changes) Min(field string, value any) Changes {
+	return changes.add("$min", field, value)
+}
+
+// Max replaces a field with the value, only if the value is greater than the
+// current value (or the field is missing).
+func (changes Changes) Max(field string, value any) Changes {
+	return changes.add("$max", field, value)
+}
+
+// Mul multiplies a numeric field by the factor.  A missing field is created
+// with a value of zero.
+func (changes Changes) Mul(field string, factor any) Changes {
+	return changes.add("$mul", field, factor)
+}
+
+// PushModifiers configure where a Push adds its values, and how large the
+// array may grow.  The zero value appends the values to the end of the array.
+type PushModifiers struct {
+	Position *int // Position inserts the values at this array index, instead of appending them (negative counts from the end)
+	Slice    *int // Slice trims the array to this many elements after the push (negative keeps the last elements)
+}
+
+// Push appends one or more values to an array field.
+func (changes Changes) Push(field string, values ...any) Changes {
+	return changes.PushModified(field, PushModifiers{}, values...)
+}
+
+// PushModified adds one or more values to an array field, using the $position
+// and $slice modifiers to control where they are added and how large the
+// array may grow.
+func (changes Changes) PushModified(field string, modifiers PushModifiers, values ...any) Changes {
+
+	push := bson.D{{Key: "$each", Value: bson.A(values)}}
+
+	if modifiers.Position != nil {
+		push = append(push, bson.E{Key: "$position", Value: *modifiers.Position})
+	}
+
+	if modifiers.Slice != nil {
+		push = append(push, bson.E{Key: "$slice", Value: *modifiers.Slice})
+	}
+
+	return changes.add("$push", field, push)
+}
+
+// Pull removes every element of an array field that matches the criteria.
+// For arrays of sub-documents, criteria fields are relative to each element
+// (such as exp.Equal("role", "admin")).  For arrays of scalar values, use an
+// empty field name to match the elements themselves (such as
+// exp.GreaterThan("", 5)), joining several with AND to match a range.
+// Criteria that cannot be translated return a 400 error, and leave the change
+// set unchanged.
+func (changes Changes) Pull(field string, criteria exp.Expression) (Changes, error) {
+
+	const location = "data-mongo.Changes.Pull"
+
+	condition, err := elemMatchBSON(criteria)
+
+	if err != nil {
+		return changes, derp.Wrap(err, location, "Translating pull criteria", field)
+	}
+
+	return changes.add("$pull", field, condition), nil
+}
+
+// PullValues removes every element of an array field that equals one of the values.
+func (changes Changes) PullValues(field string, values ...any) Changes {
+	return changes.add("$pull", field, bson.M{"$in": bson.A(values)})
+}
+
+// AddToSet adds one or more values to an array field, skipping any values
+// that the array already contains.
+func (changes Changes) AddToSet(field string, values ...any) Changes {
+	return changes.add("$addToSet", field, bson.M{"$each": bson.A(values)})
+}
+
+// IsEmpty returns TRUE if the change set contains no modifications.
+func (changes Changes) IsEmpty() bool {
+	for _, fields := range changes {
+		if len(fields) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BSON returns the mongodb update document for this change set.
+func (changes Changes) BSON() bson.M {
+
+	result := bson.M{}
+
+	for operator, fields := range changes {
+		if len(fields) > 0 {
+			result[operator] = fields
+		}
+	}
+
+	return result
+}
+
+// add records a single field modification under the given operator,
+// allocating the change set (or the operator) on first use.
+func (changes Changes) add(operator string, field string, value any) Changes {
+
+	if changes == nil {
+		changes = Changes{}
+	}
+
+	if changes[operator] == nil {
+		changes[operator] = bson.M{}
+	}
+
+	changes[operator][field] = value
+	return changes
+}
+
+// clone returns a deep copy of the change set, so that it can be extended
+// without modifying the caller's value.
+func (changes Changes) clone() Changes {
+
+	result := make(Changes, len(changes))
+
+	for operator, fields := range changes {
+		copied := make(bson.M, len(fields))
+		for field, value := range fields {
+			copied[field] = value
+		}
+		result[operator] = copied
+	}
+
+	return result
+}
+
+// UpdateResult reports how many documents were affected by an in-place update.
+type UpdateResult struct {
+	MatchedCount  int64 // MatchedCount is the number of documents that matched the criteria
+	ModifiedCount int64 // ModifiedCount is the number of documents that were actually changed
+	Inserted      bool  // Inserted is TRUE if an upsert inserted a new document instead of updating an existing one
+}
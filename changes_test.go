@@ -0,0 +1,191 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/******************************************
+ * Changes
+ ******************************************/
+
+// The zero value is usable, and each method adds to the correct operator.
+func TestChanges_Operators(t *testing.T) {
+
+	changes := Changes{}.
+		Set("name", "Sarah").
+		Unset("nickname").
+		Inc("logins", 1)
+
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"name": "Sarah"},
+		"$unset": bson.M{"nickname": ""},
+		"$inc":   bson.M{"logins": 1},
+	}, changes.BSON())
+}
+
+// A nil change set allocates itself on first use.
+func TestChanges_Nil(t *testing.T) {
+
+	var changes Changes
+	assert.True(t, changes.IsEmpty())
+
+	changes = changes.Set("name", "Sarah")
+	assert.False(t, changes.IsEmpty())
+	assert.Equal(t, bson.M{"$set": bson.M{"name": "Sarah"}}, changes.BSON())
+}
+
+func TestChanges_SetAll(t *testing.T) {
+
+	changes := Changes{}.SetAll(map[string]any{"name": "Sarah", "age": 45})
+
+	assert.Equal(t, bson.M{"$set": bson.M{"name": "Sarah", "age": 45}}, changes.BSON())
+}
+
+// Operators with no fields are not considered changes, and are omitted from BSON.
+func TestChanges_IsEmpty(t *testing.T) {
+
+	changes := Changes{"$set": bson.M{}}
+
+	assert.True(t, changes.IsEmpty())
+	assert.Equal(t, bson.M{}, changes.BSON())
+}
+
+func TestChanges_MinMaxMul(t *testing.T) {
+
+	changes := Changes{}.Min("lowScore", 10).Max("highScore", 90).Mul("price", 1.1)
+
+	assert.Equal(t, bson.M{
+		"$min": bson.M{"lowScore": 10},
+		"$max": bson.M{"highScore": 90},
+		"$mul": bson.M{"price": 1.1},
+	}, changes.BSON())
+}
+
+/******************************************
+ * Changes - Arrays
+ ******************************************/
+
+func TestChanges_Push(t *testing.T) {
+
+	changes := Changes{}.Push("tags", "a", "b")
+
+	assert.Equal(t, bson.M{
+		"$push": bson.M{"tags": bson.D{{Key: "$each", Value: bson.A{"a", "b"}}}},
+	}, changes.BSON())
+}
+
+func TestChanges_PushModified(t *testing.T) {
+
+	changes := Changes{}.PushModified("tags", PushModifiers{Position: pointerTo(0), Slice: pointerTo(-5)}, "a")
+
+	assert.Equal(t, bson.M{
+		"$push": bson.M{"tags": bson.D{
+			{Key: "$each", Value: bson.A{"a"}},
+			{Key: "$position", Value: 0},
+			{Key: "$slice", Value: -5},
+		}},
+	}, changes.BSON())
+}
+
+// Pull criteria are translated with ExpressionToBSON, relative to each element.
+func TestChanges_Pull(t *testing.T) {
+
+	changes, err := Changes{}.Pull("recipients", exp.Equal("role", "bcc").AndEqual("status", "bounced"))
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.M{
+		"$pull": bson.M{"recipients": bson.M{"$and": bson.A{
+			bson.M{"role": bson.M{"$eq": "bcc"}},
+			bson.M{"status": bson.M{"$eq": "bounced"}},
+		}}},
+	}, changes.BSON())
+}
+
+// An empty field name applies the condition to scalar elements directly.
+func TestChanges_PullScalar(t *testing.T) {
+
+	changes, err := Changes{}.Pull("scores", exp.GreaterThan("", 5))
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.M{
+		"$pull": bson.M{"scores": bson.M{"$gt": 5}},
+	}, changes.BSON())
+}
+
+// Scalar predicates joined by AND are merged, so that a range matches each
+// element, and mixing them with sub-document fields is refused.
+func TestChanges_PullScalarRange(t *testing.T) {
+
+	changes, err := Changes{}.Pull("scores", exp.GreaterThan("", 5).AndLessThan("", 10))
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.M{
+		"$pull": bson.M{"scores": bson.M{"$gt": 5, "$lt": 10}},
+	}, changes.BSON())
+
+	_, err = Changes{}.Pull("scores", exp.GreaterThan("", 5).AndEqual("role", "bcc"))
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+// An untranslatable condition is refused, and leaves the change set unchanged.
+func TestChanges_PullInvalid(t *testing.T) {
+
+	changes, err := Changes{}.Set("name", "Sarah").Pull("recipients", exp.New("role", "unknown-operator", "bcc"))
+	assert.True(t, derp.IsBadRequest(err))
+
+	assert.Equal(t, bson.M{
+		"$set": bson.M{"name": "Sarah"},
+	}, changes.BSON())
+}
+
+func TestChanges_PullValues(t *testing.T) {
+
+	changes := Changes{}.PullValues("tags", "a", "b")
+
+	assert.Equal(t, bson.M{
+		"$pull": bson.M{"tags": bson.M{"$in": bson.A{"a", "b"}}},
+	}, changes.BSON())
+}
+
+func TestChanges_AddToSet(t *testing.T) {
+
+	changes := Changes{}.AddToSet("tags", "a", "b")
+
+	assert.Equal(t, bson.M{
+		"$addToSet": bson.M{"tags": bson.M{"$each": bson.A{"a", "b"}}},
+	}, changes.BSON())
+}
+
+/******************************************
+ * stampUpdated()
+ ******************************************/
+
+// Stamping the journal adds the update date, revision, and note without
+// modifying the caller's change set.
+func TestStampUpdated(t *testing.T) {
+
+	changes := Changes{}.Set("name", "Sarah")
+	stamped := stampUpdated(changes, "renamed")
+
+	require.Contains(t, stamped["$set"], journalUpdateDate)
+	assert.Equal(t, "renamed", stamped["$set"][journalNote])
+	assert.Equal(t, 1, stamped["$inc"][journalRevision])
+	assert.Equal(t, "Sarah", stamped["$set"]["name"])
+
+	// The original change set is untouched.
+	assert.Equal(t, bson.M{"$set": bson.M{"name": "Sarah"}}, changes.BSON())
+}
+
+// An empty note does not overwrite the previous note.
+func TestStampUpdated_EmptyNote(t *testing.T) {
+
+	stamped := stampUpdated(Changes{}.Set("name", "Sarah"), "")
+
+	assert.NotContains(t, stamped["$set"], journalNote)
+}
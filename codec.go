@@ -0,0 +1,60 @@
+package mongodb
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/benpate/derp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IDCodec converts the string returned by data.Object.ID() into the value that
+// is stored in the document's `_id` field, so that objects can be matched by
+// their primary key.  Register a codec with the WithIDCodec setting.
+type IDCodec func(id string) (any, error)
+
+// ObjectIDCodec decodes hex-encoded mongodb ObjectIDs.  This is the default
+// codec for every Collection.
+func ObjectIDCodec(id string) (any, error) {
+	return primitive.ObjectIDFromHex(id)
+}
+
+// StringIDCodec uses the ID exactly as it is, for documents whose primary key
+// is stored as a string (such as a slug, URL, or composite key).
+func StringIDCodec(id string) (any, error) {
+
+	const location = "data-mongo.StringIDCodec"
+
+	if id == "" {
+		return nil, derp.BadRequest(location, "ID must not be empty")
+	}
+
+	return id, nil
+}
+
+// UUIDCodec decodes a canonical UUID string (with or without hyphens) into the
+// standard BSON UUID representation (binary subtype 4).
+func UUIDCodec(id string) (any, error) {
+
+	const location = "data-mongo.UUIDCodec"
+
+	if len(id) == 36 {
+		if id[8] != '-' || id[13] != '-' || id[18] != '-' || id[23] != '-' {
+			return nil, derp.BadRequest(location, "Invalid UUID format", id)
+		}
+		id = strings.ReplaceAll(id, "-", "")
+	}
+
+	if len(id) != 32 {
+		return nil, derp.BadRequest(location, "Invalid UUID length", id)
+	}
+
+	value, err := hex.DecodeString(id)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid UUID characters", id, derp.WithBadRequest())
+	}
+
+	return primitive.Binary{Subtype: bson.TypeBinaryUUID, Data: value}, nil
+}
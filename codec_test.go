@@ -0,0 +1,110 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/derp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/******************************************
+ * ObjectIDCodec()
+ ******************************************/
+
+func TestObjectIDCodec(t *testing.T) {
+
+	objectID := primitive.NewObjectID()
+
+	result, err := ObjectIDCodec(objectID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, objectID, result)
+
+	_, err = ObjectIDCodec("not-an-object-id")
+	assert.ErrorIs(t, err, primitive.ErrInvalidHex)
+}
+
+/******************************************
+ * StringIDCodec()
+ ******************************************/
+
+func TestStringIDCodec(t *testing.T) {
+
+	result, err := StringIDCodec("https://example.com/users/sarah")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/users/sarah", result)
+
+	_, err = StringIDCodec("")
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+/******************************************
+ * UUIDCodec()
+ ******************************************/
+
+func TestUUIDCodec(t *testing.T) {
+
+	expected := primitive.Binary{
+		Subtype: bson.TypeBinaryUUID,
+		Data:    []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+	}
+
+	// Canonical form, with hyphens
+	result, err := UUIDCodec("123e4567-e89b-12d3-a456-426614174000")
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// Compact form, without hyphens
+	result, err = UUIDCodec("123e4567e89b12d3a456426614174000")
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// Upper case hex digits
+	result, err = UUIDCodec("123E4567-E89B-12D3-A456-426614174000")
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestUUIDCodec_Invalid(t *testing.T) {
+
+	invalid := []string{
+		"",
+		"not-a-uuid",
+		"123e4567-e89b-12d3-a456-42661417400",   // too short
+		"123e4567-e89b-12d3-a456-4266141740000", // too long
+		"123e4567xe89b-12d3-a456-426614174000",  // misplaced hyphen
+		"123e4567-e89b-12d3-a456-42661417400g",  // not hex
+	}
+
+	for _, value := range invalid {
+		_, err := UUIDCodec(value)
+		require.Error(t, err, "value=%q", value)
+		assert.True(t, derp.IsBadRequest(err), "value=%q", value)
+	}
+}
+
+/******************************************
+ * settings.decodeID()
+ ******************************************/
+
+// Without a codec, IDs are decoded as ObjectIDs.
+func TestSettings_DecodeID(t *testing.T) {
+
+	objectID := primitive.NewObjectID()
+
+	result, err := settings{}.decodeID(objectID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, objectID, result)
+
+	result, err = settings{}.apply(WithIDCodec(StringIDCodec)).decodeID("sarah")
+	require.NoError(t, err)
+	assert.Equal(t, "sarah", result)
+
+	// A nil codec restores the default.
+	result, err = settings{}.apply(WithIDCodec(StringIDCodec), WithIDCodec(nil)).decodeID(objectID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, objectID, result)
+}
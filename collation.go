@@ -0,0 +1,52 @@
+package mongodb
+
+import (
+	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collation controls how strings are compared when matching and sorting,
+// following the rules of a particular language.  See the MongoDB collation
+// documentation for the valid values of each field.
+type Collation struct {
+	Locale          string // The ICU locale, such as "en", "de", "tr", or "ja".  Defaults to "en"
+	Strength        int    // The level of comparison, from 1 to 5.  Zero uses the server default (3)
+	NumericOrdering bool   // If TRUE, numeric strings are compared as numbers, so "10" sorts after "9"
+	Alternate       string // Whether spaces and punctuation are "non-ignorable" (the default) or "shifted"
+}
+
+// defaultCollationLocale is the locale used when none is configured
+const defaultCollationLocale = "en"
+
+// collationStrengthCaseInsensitive compares base characters and accents, but not case
+const collationStrengthCaseInsensitive = 2
+
+// collationStrengthCaseSensitive compares base characters, accents, and case
+const collationStrengthCaseSensitive = 3
+
+// withCaseSensitive returns a copy of this Collation with the strength set to
+// match or ignore case.
+func (collation Collation) withCaseSensitive(caseSensitive bool) Collation {
+
+	if caseSensitive {
+		collation.Strength = collationStrengthCaseSensitive
+	} else {
+		collation.Strength = collationStrengthCaseInsensitive
+	}
+
+	return collation
+}
+
+// mongo returns the mongodb Collation for this Collation
+func (collation Collation) mongo() *mongoOptions.Collation {
+
+	if collation.Locale == "" {
+		collation.Locale = defaultCollationLocale
+	}
+
+	return &mongoOptions.Collation{
+		Locale:          collation.Locale,
+		Strength:        collation.Strength,
+		NumericOrdering: collation.NumericOrdering,
+		Alternate:       collation.Alternate,
+	}
+}
@@ -0,0 +1,31 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollation_Mongo(t *testing.T) {
+
+	// The locale defaults to "en"
+	result := Collation{}.mongo()
+	assert.Equal(t, "en", result.Locale)
+	assert.Zero(t, result.Strength)
+
+	// Every other field is passed through
+	result = Collation{Locale: "de", Strength: 1, NumericOrdering: true, Alternate: "shifted"}.mongo()
+	assert.Equal(t, "de", result.Locale)
+	assert.Equal(t, 1, result.Strength)
+	assert.True(t, result.NumericOrdering)
+	assert.Equal(t, "shifted", result.Alternate)
+}
+
+func TestCollation_WithCaseSensitive(t *testing.T) {
+
+	collation := Collation{Locale: "tr", Strength: 1}
+
+	assert.Equal(t, Collation{Locale: "tr", Strength: 3}, collation.withCaseSensitive(true))
+	assert.Equal(t, Collation{Locale: "tr", Strength: 2}, collation.withCaseSensitive(false))
+	assert.Equal(t, 1, collation.Strength) // the original is unchanged
+}
@@ -11,24 +11,32 @@ import (
 	"github.com/benpate/derp"
 	"github.com/benpate/exp"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Collection wraps a mongodb.Collection with all of the methods required by the data.Collection interface
 type Collection struct {
 	collection *mongo.Collection
 	context    context.Context
+	settings   settings
 }
 
 // NewCollection creates a new Collection object directly from a mongo.Collection
-func NewCollection(collection *mongo.Collection) Collection {
+func NewCollection(collection *mongo.Collection, list ...Setting) Collection {
 	return Collection{
 		collection: collection,
 		context:    context.Background(),
+		settings:   settings{}.apply(list...),
 	}
 }
 
+// With returns a copy of this Collection with additional settings applied.
+func (c Collection) With(list ...Setting) Collection {
+	c.settings = c.settings.apply(list...)
+	return c
+}
+
 // Context returns the context associated with this collection.
 func (c Collection) Context() context.Context {
 	return c.context
@@ -39,13 +47,19 @@ func (c Collection) Count(criteria exp.Expression, options ...option.Option) (in
 
 	const location = "data-mongo.Collection.Count"
 
-	criteriaBSON := ExpressionToBSON(criteria)
+	options = c.queryOptions(options...)
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return 0, derp.Wrap(err, location, "Invalid criteria")
+	}
+
 	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
 
 	count, err := c.collection.CountDocuments(c.context, criteriaBSON, countOptions(options...))
 
 	if err != nil {
-		return 0, derp.Wrap(err, location, "Counting objects", criteriaBSON, derp.WithCode(http.StatusInternalServerError))
+		return 0, derp.Wrap(err, location, "Counting objects", criteriaBSON, derp.WithCode(queryErrorCode(err)))
 	}
 
 	return count, nil
@@ -56,18 +70,29 @@ func (c Collection) Query(target any, criteria exp.Expression, options ...option
 
 	const location = "data-mongo.Collection.Query"
 
-	criteriaBSON := ExpressionToBSON(criteria)
+	options = c.queryOptions(options...)
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid criteria")
+	}
+
 	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
 
-	optionsBSON := findOptions(options...)
+	optionsBSON, err := findOptions(options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid options")
+	}
+
 	cursor, err := c.collection.Find(c.context, criteriaBSON, optionsBSON)
 
 	if err != nil {
-		return derp.Wrap(err, location, "Listing objects", criteriaBSON, options, derp.WithCode(http.StatusInternalServerError))
+		return derp.Wrap(err, location, "Listing objects", criteriaBSON, options, derp.WithCode(queryErrorCode(err)))
 	}
 
 	if err := cursor.All(c.context, target); err != nil {
-		return derp.Wrap(err, location, "Unmarshaling database objects", criteriaBSON, options)
+		return derp.Wrap(err, location, "Unmarshaling database objects", criteriaBSON, options, derp.WithCode(queryErrorCode(err)))
 	}
 
 	return nil
@@ -78,17 +103,43 @@ func (c Collection) Iterator(criteria exp.Expression, options ...option.Option)
 
 	const location = "data-mongo.Collection.Iterator"
 
-	criteriaBSON := ExpressionToBSON(criteria)
+	options = c.queryOptions(options...)
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return NewIterator(c.context, nil), derp.Wrap(err, location, "Invalid criteria")
+	}
+
 	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
 
-	optionsBSON := findOptions(options...)
+	optionsBSON, err := findOptions(options...)
+
+	if err != nil {
+		return NewIterator(c.context, nil), derp.Wrap(err, location, "Invalid options")
+	}
+
 	cursor, err := c.collection.Find(c.context, criteriaBSON, optionsBSON)
 
 	if err != nil {
-		return NewIterator(c.context, cursor), derp.Wrap(err, location, "Listing objects", criteria, criteriaBSON, options, derp.WithCode(http.StatusInternalServerError))
+		return NewIterator(c.context, cursor), derp.Wrap(err, location, "Listing objects", criteria, criteriaBSON, options, derp.WithCode(queryErrorCode(err)))
 	}
 
 	iterator := NewIterator(c.context, cursor)
+	iterator.state.skipInvalid = hasSkipInvalid(options...)
+
+	// Count every matching record, if requested
+	if hasCountTotal(options...) {
+
+		total, err := c.collection.CountDocuments(c.context, criteriaBSON, totalOptions(options...))
+
+		if err != nil {
+			_ = iterator.Close()
+			return NewIterator(c.context, nil), derp.Wrap(err, location, "Counting objects", criteria, criteriaBSON, options, derp.WithCode(queryErrorCode(err)))
+		}
+
+		iterator.state.total = total
+		iterator.state.hasTotal = true
+	}
 
 	return iterator, nil
 }
@@ -98,10 +149,20 @@ func (c Collection) Load(criteria exp.Expression, target data.Object, options ..
 
 	const location = "data-mongo.Collection.Load"
 
-	criteriaBSON := ExpressionToBSON(criteria)
+	options = c.queryOptions(options...)
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid criteria")
+	}
+
 	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
 
-	optionsBSON := findOneOptions(options...)
+	optionsBSON, err := findOneOptions(options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid options")
+	}
 
 	// Try to query the database
 	if err := c.collection.FindOne(c.context, criteriaBSON, optionsBSON).Decode(target); err != nil {
@@ -110,15 +171,27 @@ func (c Collection) Load(criteria exp.Expression, target data.Object, options ..
 			return derp.Wrap(err, location, "Loading object", criteria, criteriaBSON, target.ID(), derp.WithCode(http.StatusNotFound))
 		}
 
-		return derp.Wrap(err, location, "Loading object", criteria, criteriaBSON, target.ID(), derp.WithCode(http.StatusInternalServerError))
+		return derp.Wrap(err, location, "Loading object", criteria, criteriaBSON, target.ID(), derp.WithCode(queryErrorCode(err)))
 	}
 
 	return nil
 }
 
-// Save inserts/updates a single object in the database.
+// Save inserts/updates a single object in the database.  When the revision
+// check is enabled, an update only succeeds if the stored document still has
+// the revision that the object was loaded with, and returns a 409 Conflict
+// otherwise.
 func (c Collection) Save(object data.Object, note string) error {
 
+	// Capture the loaded revision before SetUpdated increments it.
+	return c.save(object, note, object.ETag())
+}
+
+// save inserts/updates a single object in the database.  The revision is the
+// one that the object was loaded with, which is checked against the stored
+// document when the revision check is enabled.
+func (c Collection) save(object data.Object, note string, revision string) error {
+
 	const location = "data-mongo.Collection.Save"
 
 	// object.ID() is read lazily, since an INSERT may assign it during this call.
@@ -140,21 +213,356 @@ func (c Collection) Save(object data.Object, note string) error {
 
 	// Fall through to here means UPDATE object
 
-	objectID, err := primitive.ObjectIDFromHex(object.ID())
+	filter, err := c.idFilter(object)
 
 	if err != nil {
 		return derp.Wrap(err, location, "Generating object ID", object.ID(), derp.WithCode(http.StatusInternalServerError))
 	}
 
-	filter := bson.M{"_id": objectID}
+	// With the revision check enabled, only replace the document if no one
+	// else has saved it since it was loaded.  The replacement carries the
+	// incremented revision, so the check and the increment are atomic.
+	if err := c.addRevision(filter, revision); err != nil {
+		return derp.Wrap(err, location, "Parsing object revision", object.ID(), revision, derp.WithCode(http.StatusInternalServerError))
+	}
 
-	if _, err := c.collection.ReplaceOne(c.context, filter, object); err != nil {
+	result, err := c.collection.ReplaceOne(c.context, filter, object)
+
+	if err != nil {
 		return derp.Wrap(err, location, "Replacing object", filter, object.ID(), derp.WithBadRequest())
 	}
 
+	if c.settings.revisionCheck && result.MatchedCount == 0 {
+		return derp.BadRequest(location, "Object was modified or removed since it was loaded", filter, object.ID(), derp.WithCode(http.StatusConflict))
+	}
+
+	return nil
+}
+
+// Update applies a set of field-level changes to every document that matches
+// the criteria, without replacing the rest of each document.  The journal of
+// each document is stamped in the same way as SetUpdated.  Virtually-deleted
+// documents are skipped when the Collection uses WithoutDeleted.
+func (c Collection) Update(criteria exp.Expression, changes Changes, note string) (UpdateResult, error) {
+
+	const location = "data-mongo.Collection.Update"
+
+	if changes.IsEmpty() {
+		return UpdateResult{}, derp.BadRequest(location, "Updating objects requires at least one change", criteria, note)
+	}
+
+	criteriaBSON, err := c.filter(criteria)
+
+	if err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
+
+	updateBSON := stampUpdated(changes, note).BSON()
+	result, err := c.collection.UpdateMany(c.context, criteriaBSON, updateBSON, updateOptions(c.queryOptions()...))
+
+	if err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Updating objects", criteriaBSON, updateBSON, derp.WithBadRequest())
+	}
+
+	return UpdateResult{
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+	}, nil
+}
+
+// Push adds one or more values to an array field in every document that
+// matches the criteria.  The modifiers control where the values are added and
+// how large the array may grow.
+func (c Collection) Push(criteria exp.Expression, field string, values []any, modifiers PushModifiers, note string) (UpdateResult, error) {
+	return c.Update(criteria, Changes{}.PushModified(field, modifiers, values...), note)
+}
+
+// Pull removes every element of an array field that matches the match
+// expression, in every document that matches the criteria.  See Changes.Pull
+// for how the match expression is applied to each element.
+func (c Collection) Pull(criteria exp.Expression, field string, match exp.Expression, note string) (UpdateResult, error) {
+
+	const location = "data-mongo.Collection.Pull"
+
+	changes, err := Changes{}.Pull(field, match)
+
+	if err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Invalid match expression", criteria, note)
+	}
+
+	return c.Update(criteria, changes, note)
+}
+
+// AddToSet adds one or more values to an array field in every document that
+// matches the criteria, skipping any values that the array already contains.
+func (c Collection) AddToSet(criteria exp.Expression, field string, values []any, note string) (UpdateResult, error) {
+	return c.Update(criteria, Changes{}.AddToSet(field, values...), note)
+}
+
+// Increment atomically adds a delta to each numeric field in the first
+// document that matches the criteria.  Missing fields start at zero, and the
+// Upsert option inserts a new document when none matches.  When a
+// ReturnBefore or ReturnAfter option is used, the field values before (or
+// after) the increment are returned; otherwise the result is nil.  Counters
+// are not journaled, so that high-frequency increments do not churn the
+// UpdateDate or revision of the document, but an upserted document is stamped
+// with a CreateDate.
+func (c Collection) Increment(criteria exp.Expression, deltas map[string]any, options ...option.Option) (map[string]any, error) {
+
+	const location = "data-mongo.Collection.Increment"
+
+	options = c.queryOptions(options...)
+
+	if len(deltas) == 0 {
+		return nil, derp.BadRequest(location, "Incrementing requires at least one field", criteria)
+	}
+
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
+
+	changes := Changes{}
+	projection := bson.M{}
+
+	for field, delta := range deltas {
+		changes = changes.Inc(field, delta)
+		projection[field] = 1
+	}
+
+	// A new document is journaled as created, even though counters are not
+	if hasUpsert(options...) {
+		changes = stampInserted(changes)
+	}
+
+	updateBSON := changes.BSON()
+
+	// Without a ReturnDocument option, no values are returned.
+	if !hasReturnDocument(options...) {
+
+		result, err := c.collection.UpdateOne(c.context, criteriaBSON, updateBSON, updateOptions(options...))
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Incrementing fields", criteriaBSON, updateBSON, derp.WithBadRequest())
+		}
+
+		if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+			return nil, derp.NotFound(location, "Incrementing fields", criteriaBSON)
+		}
+
+		return nil, nil
+	}
+
+	optionsBSON, err := findOneAndUpdateOptions(options...)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid options")
+	}
+
+	optionsBSON.SetProjection(projection)
+
+	document := bson.M{}
+
+	err = c.collection.FindOneAndUpdate(c.context, criteriaBSON, updateBSON, optionsBSON).Decode(&document)
+
+	switch {
+
+	case err == nil:
+
+	// An upsert that returns the "before" version of a new document has no previous values.
+	case err == mongo.ErrNoDocuments && optionsBSON.Upsert != nil:
+
+	case err == mongo.ErrNoDocuments:
+		return nil, derp.Wrap(err, location, "Incrementing fields", criteriaBSON, derp.WithCode(http.StatusNotFound))
+
+	default:
+		return nil, derp.Wrap(err, location, "Incrementing fields", criteriaBSON, updateBSON, derp.WithBadRequest())
+	}
+
+	result := make(map[string]any, len(deltas))
+
+	for field := range deltas {
+		result[field] = lookupPath(document, field)
+	}
+
+	return result, nil
+}
+
+// FindOneAndUpdate atomically applies a set of changes to the first document
+// that matches the criteria (in the order of any Sort options) and populates
+// the target with it.  The document is returned as it was before the changes
+// unless the ReturnAfter option is used.  The journal is stamped in the same
+// way as Update.  With the Upsert option, a new document is inserted (with a
+// CreateDate) when none matches; since it has no previous version, the target
+// is only populated when ReturnAfter is also used.
+func (c Collection) FindOneAndUpdate(criteria exp.Expression, changes Changes, target data.Object, note string, options ...option.Option) error {
+
+	const location = "data-mongo.Collection.FindOneAndUpdate"
+
+	options = c.queryOptions(options...)
+
+	if changes.IsEmpty() {
+		return derp.BadRequest(location, "Updating object requires at least one change", criteria, note)
+	}
+
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
+
+	changes = stampUpdated(changes, note)
+	upsert := hasUpsert(options...)
+
+	if upsert {
+		changes = stampInserted(changes)
+	}
+
+	updateBSON := changes.BSON()
+	optionsBSON, err := findOneAndUpdateOptions(options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid options")
+	}
+
+	if err := c.collection.FindOneAndUpdate(c.context, criteriaBSON, updateBSON, optionsBSON).Decode(target); err != nil {
+
+		// An upsert that returns the "before" version of a new document has no previous version.
+		if (err == mongo.ErrNoDocuments) && upsert {
+			return nil
+		}
+
+		if err == mongo.ErrNoDocuments {
+			return derp.Wrap(err, location, "Updating object", criteria, criteriaBSON, derp.WithCode(http.StatusNotFound))
+		}
+
+		return derp.Wrap(err, location, "Updating object", criteria, criteriaBSON, updateBSON, derp.WithCode(queryErrorCode(err)))
+	}
+
+	return nil
+}
+
+// FindOneAndDelete atomically removes the first document that matches the
+// criteria (in the order of any Sort options) and populates the target with
+// it.  Like HardDelete, this physically removes the document.
+func (c Collection) FindOneAndDelete(criteria exp.Expression, target data.Object, options ...option.Option) error {
+
+	const location = "data-mongo.Collection.FindOneAndDelete"
+
+	options = c.queryOptions(options...)
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
+
+	optionsBSON, err := findOneAndDeleteOptions(options...)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid options")
+	}
+
+	if err := c.collection.FindOneAndDelete(c.context, criteriaBSON, optionsBSON).Decode(target); err != nil {
+
+		if err == mongo.ErrNoDocuments {
+			return derp.Wrap(err, location, "Deleting object", criteria, criteriaBSON, derp.WithCode(http.StatusNotFound))
+		}
+
+		return derp.Wrap(err, location, "Deleting object", criteria, criteriaBSON, derp.WithCode(queryErrorCode(err)))
+	}
+
 	return nil
 }
 
+// Upsert saves an object into the document that matches the criteria (such as
+// a natural key from an external system), inserting a new document when none
+// matches.  An existing document keeps its own `_id`, CreateDate and
+// DeleteDate, and its revision is incremented in the same way as Update.  The
+// stored document is then decoded back into the object, so that its `_id` and
+// journal match the database, and a later Save updates the same document.
+// Virtually-deleted documents are not matched when the Collection uses
+// WithoutDeleted.
+func (c Collection) Upsert(criteria exp.Expression, object data.Object, note string) (UpdateResult, error) {
+
+	const location = "data-mongo.Collection.Upsert"
+
+	criteriaBSON, err := c.filter(criteria)
+
+	if err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
+
+	object.SetUpdated(note)
+
+	fields, err := documentFields(object)
+
+	if err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Marshalling object", object.ID(), derp.WithCode(http.StatusInternalServerError))
+	}
+
+	// Fields that identify a new document are only written on INSERT.
+	createDate := object.Updated()
+	insertFields := bson.M{journalCreateDate: createDate}
+	delete(fields, journalCreateDate)
+
+	id, hasID := fields["_id"]
+
+	if hasID {
+		insertFields["_id"] = id
+		delete(fields, "_id")
+	}
+
+	// The stored journal belongs to the stored document, so the incoming
+	// object's revision and dates must not overwrite it.  Instead, the journal
+	// is stamped in place, which increments the stored revision.
+	delete(fields, journalRevision)
+	delete(fields, journalUpdateDate)
+	delete(fields, journalDeleteDate)
+
+	updateBSON := stampUpdated(Changes{}.SetAll(fields), note).BSON()
+	updateBSON["$setOnInsert"] = insertFields
+
+	optionsBSON, err := findOneAndUpdateOptions(c.queryOptions(Upsert(), ReturnAfter())...)
+
+	if err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Invalid options")
+	}
+
+	document := bson.Raw{}
+
+	if err := c.collection.FindOneAndUpdate(c.context, criteriaBSON, updateBSON, optionsBSON).Decode(&document); err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Upserting object", criteriaBSON, object.ID(), derp.WithBadRequest())
+	}
+
+	if err := bson.Unmarshal(document, object); err != nil {
+		return UpdateResult{}, derp.Wrap(err, location, "Unmarshalling stored object", criteriaBSON, object.ID(), derp.WithCode(http.StatusInternalServerError))
+	}
+
+	// A new document has the values that were only written on INSERT
+	inserted := (object.Created() == createDate)
+
+	if hasID {
+		inserted = inserted && document.Lookup("_id").Equal(rawValue(id))
+	}
+
+	if inserted {
+		return UpdateResult{Inserted: true}, nil
+	}
+
+	return UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
 // Delete removes a single object from the database, using a "virtual delete"
 func (c Collection) Delete(object data.Object, note string) error {
 
@@ -166,25 +574,110 @@ func (c Collection) Delete(object data.Object, note string) error {
 		return derp.BadRequest(location, "Deleting unsaved object", object.ID(), note)
 	}
 
-	// Use virtual delete to mark this object as deleted.
+	// Use virtual delete to mark this object as deleted.  The loaded revision
+	// is captured first, because SetDeleted increments it.
+	revision := object.ETag()
 	object.SetDeleted(note)
 
-	if err := c.Save(object, note); err != nil {
-		return derp.Wrap(err, location, "Performing virtual delete", object.ID(), derp.WithCode(http.StatusInternalServerError))
+	if err := c.save(object, note, revision); err != nil {
+		return derp.Wrap(err, location, "Performing virtual delete", object.ID())
+	}
+
+	return nil
+}
+
+// Restore reverses a virtual delete, clearing the DeleteDate from the journal
+// of a deleted object.  The object is reloaded from the database once it has
+// been restored.  It fails with a 404 error if the document is missing or not
+// deleted, and (with the revision check enabled) with a 409 error if the
+// deleted document has been changed since it was loaded.
+func (c Collection) Restore(object data.Object, note string) error {
+
+	const location = "data-mongo.Collection.Restore"
+
+	defer c.reportIfSlow(location, startTimer(), object.ID())
+
+	if !object.IsDeleted() {
+		return derp.BadRequest(location, "Restoring object that is not deleted", object.ID(), note)
+	}
+
+	filter, err := c.idFilter(object)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Generating object ID", object.ID(), derp.WithCode(http.StatusInternalServerError))
+	}
+
+	if err := c.addRevision(filter, object.ETag()); err != nil {
+		return derp.Wrap(err, location, "Parsing object revision", object.ID(), object.ETag(), derp.WithCode(http.StatusInternalServerError))
+	}
+
+	// Only restore documents that are still deleted.
+	filter[journalDeleteDate] = bson.M{"$gt": 0}
+
+	updateBSON := stampUpdated(Changes{}.Set(journalDeleteDate, 0), note).BSON()
+	optionsBSON := mongoOptions.FindOneAndUpdate().SetReturnDocument(mongoOptions.After)
+
+	if err := c.collection.FindOneAndUpdate(c.context, filter, updateBSON, optionsBSON).Decode(object); err != nil {
+
+		if err != mongo.ErrNoDocuments {
+			return derp.Wrap(err, location, "Restoring object", filter, object.ID(), derp.WithBadRequest())
+		}
+
+		// When the revision is the only condition that failed, someone else
+		// has changed the deleted document since it was loaded
+		if c.settings.revisionCheck {
+
+			delete(filter, journalRevision)
+
+			count, countErr := c.collection.CountDocuments(c.context, filter, mongoOptions.Count().SetLimit(1))
+
+			if countErr != nil {
+				return derp.Wrap(countErr, location, "Checking for a deleted object", filter, object.ID(), derp.WithCode(queryErrorCode(countErr)))
+			}
+
+			if count > 0 {
+				return derp.BadRequest(location, "Object was modified since it was loaded", filter, object.ID(), derp.WithCode(http.StatusConflict))
+			}
+		}
+
+		return derp.Wrap(err, location, "Restoring object", filter, object.ID(), derp.WithCode(http.StatusNotFound))
 	}
 
 	return nil
 }
 
+// Purge physically removes every document that was virtually deleted before
+// the cutoff time, and returns the number of documents removed.
+func (c Collection) Purge(olderThan time.Time) (int64, error) {
+
+	const location = "data-mongo.Collection.Purge"
+
+	filter := bson.M{journalDeleteDate: bson.M{"$gt": 0, "$lt": olderThan.UnixMilli()}}
+	defer c.reportIfSlow(location, startTimer(), filter)
+
+	result, err := c.collection.DeleteMany(c.context, filter)
+
+	if err != nil {
+		return 0, derp.Wrap(err, location, "Purging deleted objects", filter, derp.WithCode(http.StatusInternalServerError))
+	}
+
+	return result.DeletedCount, nil
+}
+
 // HardDelete physically removes an object from the database.
 func (c Collection) HardDelete(criteria exp.Expression) error {
 
 	const location = "data-mongo.Collection.HardDelete"
 
-	criteriaBSON := ExpressionToBSON(criteria)
+	criteriaBSON, err := TranslateExpression(criteria)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Invalid criteria")
+	}
+
 	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
 
-	if _, err := c.collection.DeleteMany(c.context, criteriaBSON); err != nil {
+	if _, err := c.collection.DeleteMany(c.context, criteriaBSON, deleteOptions(c.queryOptions()...)); err != nil {
 		return derp.Wrap(err, location, "Hard-deleting object", criteria)
 	}
 
@@ -196,6 +689,100 @@ func (c Collection) Mongo() *mongo.Collection {
 	return c.collection
 }
 
+// idFilter returns a mongodb filter that matches the stored document for an
+// object, converting its ID with the configured IDCodec.
+func (c Collection) idFilter(object data.Object) (bson.M, error) {
+
+	objectID, err := c.settings.decodeID(object.ID())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{"_id": objectID}, nil
+}
+
+// addRevision adds the expected revision to a filter when the revision check
+// is enabled, so that only an unchanged document matches.
+func (c Collection) addRevision(filter bson.M, revision string) error {
+
+	if !c.settings.revisionCheck {
+		return nil
+	}
+
+	expected, err := strconv.ParseInt(revision, 10, 64)
+
+	if err != nil {
+		return err
+	}
+
+	filter[journalRevision] = expected
+	return nil
+}
+
+// filter translates the criteria into a mongodb filter.  When the Collection
+// excludes virtually-deleted documents (and the options do not include them
+// again) a "not deleted" predicate is ANDed into the result.  It returns a 400
+// error if the criteria cannot be translated exactly, so that a bad expression
+// is never run as a wider query than intended.
+func (c Collection) filter(criteria exp.Expression, options ...option.Option) (bson.M, error) {
+
+	const location = "data-mongo.Collection.filter"
+
+	result, err := TranslateExpression(criteria)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	if !c.settings.excludeDeleted || hasIncludeDeleted(options...) {
+		return result, nil
+	}
+
+	// A missing deleteDate counts as "not deleted", just like a zero value.
+	notDeleted := bson.M{journalDeleteDate: bson.M{"$not": bson.M{"$gt": 0}}}
+
+	if len(result) == 0 {
+		return notDeleted, nil
+	}
+
+	return bson.M{"$and": bson.A{result, notDeleted}}, nil
+}
+
+// queryOptions returns the options for a query, preceded by options for the
+// default time budget and collation (if any) so that the options in the query
+// take precedence.
+func (c Collection) queryOptions(options ...option.Option) []option.Option {
+
+	defaults := make([]option.Option, 0, 2)
+
+	if c.settings.maxTime > 0 {
+		defaults = append(defaults, MaxTime(c.settings.maxTime))
+	}
+
+	if c.settings.collation != nil {
+		defaults = append(defaults, Collate(*c.settings.collation))
+	}
+
+	if len(defaults) == 0 {
+		return options
+	}
+
+	return append(defaults, options...)
+}
+
+// queryErrorCode returns the HTTP status code for a failed query: 504 Gateway
+// Timeout when the query exceeded its time budget (or its context deadline),
+// and 500 Internal Server Error otherwise.
+func queryErrorCode(err error) int {
+
+	if mongo.IsTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
+
 // reportIfSlow logs a slow-query warning when the time elapsed since startTime
 // exceeds the configured threshold.  It is meant to be deferred at the top of
 // each query method.
@@ -12,6 +12,7 @@ import (
 	"github.com/benpate/exp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -56,6 +57,111 @@ func TestCollection_Context(t *testing.T) {
 	assert.Equal(t, "abc", collection.Context().Value(ctxKey("trace")))
 }
 
+/******************************************
+ * Time Budgets
+ ******************************************/
+
+// The default time budget precedes the query's options, so that a MaxTime
+// option in the query takes precedence.
+func TestCollection_QueryOptions(t *testing.T) {
+
+	collection := Collection{}
+	assert.Empty(t, collection.queryOptions())
+	assert.Equal(t, []option.Option{option.MaxRows(1)}, collection.queryOptions(option.MaxRows(1)))
+
+	collection = collection.With(WithMaxTime(time.Second))
+	assert.Equal(t, []option.Option{MaxTime(time.Second)}, collection.queryOptions())
+	assert.Nil(t, mustFindOptions(t, collection.queryOptions(MaxTime(0))...).MaxTime)
+
+	collection = collection.With(WithCollation(Collation{Locale: "de"}))
+	assert.Equal(t, []option.Option{MaxTime(time.Second), Collate(Collation{Locale: "de"})}, collection.queryOptions())
+	assert.Equal(t, "ja", mustFindOptions(t, collection.queryOptions(Collate(Collation{Locale: "ja"}))...).Collation.Locale)
+}
+
+// The default collation applies to every query, and can be overridden.
+func TestCollection_Collation(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithCollation(Collation{Locale: "en", Strength: 2, NumericOrdering: true}))
+	seedPeople(t, collection,
+		newTestPerson("Item 10", 1),
+		newTestPerson("Item 9", 2),
+		newTestPerson("item 100", 3),
+	)
+
+	// Numeric ordering sorts "9" before "10"
+	results := make([]testPerson, 0)
+	require.NoError(t, collection.Query(&results, exp.All(), option.SortAsc("name")))
+	require.Len(t, results, 3)
+	assert.Equal(t, []string{"Item 9", "Item 10", "item 100"}, []string{results[0].Name, results[1].Name, results[2].Name})
+
+	// Strength 2 ignores case
+	count, err := collection.Count(exp.Equal("name", "ITEM 9"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// A CaseSensitive option overrides the strength
+	count, err = collection.Count(exp.Equal("name", "ITEM 9"), option.CaseSensitive(true))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// A Collate option replaces the default
+	require.NoError(t, collection.Query(&results, exp.All(), option.SortAsc("name"), Collate(Collation{Locale: "en"})))
+	assert.Equal(t, "Item 10", results[0].Name)
+
+	// Writes match the same documents as reads
+	updated, err := collection.Update(exp.Equal("name", "ITEM 9"), Changes{}.Set("age", 20), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated.MatchedCount)
+
+	upserted, err := collection.Upsert(exp.Equal("name", "ITEM 10"), newTestPerson("Item 10", 21), "")
+	require.NoError(t, err)
+	assert.False(t, upserted.Inserted)
+
+	require.NoError(t, collection.HardDelete(exp.Equal("name", "ITEM 100")))
+
+	count, err = collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestQueryErrorCode(t *testing.T) {
+	assert.Equal(t, http.StatusGatewayTimeout, queryErrorCode(context.DeadlineExceeded))
+	assert.Equal(t, http.StatusInternalServerError, queryErrorCode(mongo.ErrClientDisconnected))
+}
+
+// Queries that run out of time fail with a 504 Gateway Timeout.
+func TestCollection_Timeout(t *testing.T) {
+
+	server := getTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	t.Cleanup(cancel)
+
+	session, err := server.Session(ctx)
+	require.NoError(t, err)
+	collection := session.Collection("testPeople").(Collection)
+
+	_, err = collection.Count(exp.All())
+	assert.Equal(t, http.StatusGatewayTimeout, derp.ErrorCode(err))
+
+	err = collection.Load(exp.All(), &testPerson{})
+	assert.Equal(t, http.StatusGatewayTimeout, derp.ErrorCode(err))
+
+	err = collection.Query(&[]testPerson{}, exp.All())
+	assert.Equal(t, http.StatusGatewayTimeout, derp.ErrorCode(err))
+}
+
+// A generous time budget does not get in the way.
+func TestCollection_MaxTime(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithMaxTime(10 * time.Second))
+	seedPeople(t, collection, newTestPerson("Sarah Connor", 45))
+
+	results := make([]testPerson, 0)
+	require.NoError(t, collection.Query(&results, exp.All(), MaxTime(5*time.Second)))
+	assert.Len(t, results, 1)
+}
+
 /******************************************
  * Save() - Insert
  ******************************************/
@@ -110,6 +216,182 @@ func TestCollection_Save_InvalidObjectID(t *testing.T) {
 	require.Error(t, err)
 }
 
+/******************************************
+ * Save() - Revision Check
+ ******************************************/
+
+// With the revision check enabled, saving a stale copy of an object returns a
+// 409 Conflict instead of overwriting the newer version.
+func TestCollection_Save_RevisionConflict(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	// Two handlers load the same document.
+	first := testPerson{}
+	second := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &first))
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &second))
+
+	// The first save wins, and increments the stored revision.
+	first.Age = 21
+	require.NoError(t, collection.Save(&first, "first"))
+
+	// The second save was based on the old revision, so it conflicts.
+	second.Name = "John"
+	err := collection.Save(&second, "second")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+
+	// The first save is still intact.
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &loaded))
+	assert.Equal(t, 21, loaded.Age)
+	assert.Equal(t, "John Connor", loaded.Name)
+	assert.Equal(t, first.Revision, loaded.Revision)
+}
+
+// Consecutive saves of the same in-memory object keep succeeding, because each
+// save advances the expected revision along with the stored one.
+func TestCollection_Save_RevisionSequential(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	for age := 21; age <= 25; age++ {
+		person.Age = age
+		require.NoError(t, collection.Save(person, "birthday"))
+	}
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &loaded))
+	assert.Equal(t, 25, loaded.Age)
+	assert.Equal(t, person.Revision, loaded.Revision)
+}
+
+// Virtual deletes are also checked, using the revision the object was loaded
+// with (before SetDeleted increments it).
+func TestCollection_Delete_RevisionCheck(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	stale := *person
+
+	// A current copy can be deleted...
+	require.NoError(t, collection.Delete(person, "deleting"))
+
+	// ...but a stale copy conflicts, and keeps the 409 status.
+	err := collection.Delete(&stale, "deleting again")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+}
+
+// Saving a document that no longer exists also conflicts.
+func TestCollection_Save_RevisionMissing(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	require.NoError(t, collection.HardDelete(exp.Equal("_id", person.PersonID)))
+
+	err := collection.Save(person, "too late")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+}
+
+// Without the revision check, the last writer wins (the default behavior).
+func TestCollection_Save_NoRevisionCheck(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	stale := *person
+
+	person.Age = 21
+	require.NoError(t, collection.Save(person, "first"))
+
+	stale.Name = "John"
+	require.NoError(t, collection.Save(&stale, "second"))
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &loaded))
+	assert.Equal(t, 20, loaded.Age)
+	assert.Equal(t, "John", loaded.Name)
+}
+
+/******************************************
+ * Save() - ID Codecs
+ ******************************************/
+
+// Objects with ObjectID keys are updated with the default codec.
+func TestCollection_Save_ObjectIDCodec(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithIDCodec(ObjectIDCodec))
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	person.Age = 21
+	require.NoError(t, collection.Save(person, "update"))
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &loaded))
+	assert.Equal(t, 21, loaded.Age)
+}
+
+// Objects with string keys (such as remote URLs) are updated with StringIDCodec.
+func TestCollection_Save_StringIDCodec(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithIDCodec(StringIDCodec))
+	record := &testRecord{RecordID: "https://example.com/records/1", Value: "first"}
+	require.NoError(t, collection.Save(record, "insert"))
+
+	record.Value = "second"
+	require.NoError(t, collection.Save(record, "update"))
+
+	loaded := testRecord{}
+	require.NoError(t, collection.Load(exp.Equal("_id", record.RecordID), &loaded))
+	assert.Equal(t, "second", loaded.Value)
+
+	// Virtual delete and restore also use the codec.
+	require.NoError(t, collection.Delete(record, "delete"))
+	require.NoError(t, collection.Restore(record, "restore"))
+	assert.False(t, record.IsDeleted())
+}
+
+// Objects with UUID keys are updated with UUIDCodec.
+func TestCollection_Save_UUIDCodec(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithIDCodec(UUIDCodec), WithRevisionCheck())
+	record := newTestUUIDRecord(t, "123e4567-e89b-12d3-a456-426614174000", "first")
+	require.NoError(t, collection.Save(record, "insert"))
+
+	record.Value = "second"
+	require.NoError(t, collection.Save(record, "update"))
+
+	loaded := testUUIDRecord{}
+	require.NoError(t, collection.Load(exp.Equal("_id", record.RecordID), &loaded))
+	assert.Equal(t, "second", loaded.Value)
+	assert.Equal(t, record.ID(), loaded.ID())
+}
+
+// The default codec cannot decode non-ObjectID keys, so these updates fail.
+func TestCollection_Save_DefaultCodecRejectsString(t *testing.T) {
+
+	collection := getTestCollection(t)
+	record := &testRecord{RecordID: "slug", Value: "first"}
+	require.NoError(t, collection.Save(record, "insert"))
+
+	err := collection.Save(record, "update")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, primitive.ErrInvalidHex)
+}
+
 /******************************************
  * Load()
  ******************************************/
@@ -153,6 +435,60 @@ func TestCollection_Load_WithFieldsOption(t *testing.T) {
 	assert.Equal(t, 0, loaded.Age)
 }
 
+// ExcludeFields removes fields while keeping everything else.
+func TestCollection_Load_ExcludeFields(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("Sarah Connor", 45))
+
+	loaded := testPerson{}
+	err := collection.Load(exp.Equal("name", "Sarah Connor"), &loaded, ExcludeFields("age", "journal"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Sarah Connor", loaded.Name)
+	assert.Equal(t, 0, loaded.Age)
+	assert.Zero(t, loaded.CreateDate)
+}
+
+// SliceField and ElemMatchField trim array fields in Load, Query and Iterator.
+func TestCollection_ArrayProjections(t *testing.T) {
+
+	message := newTestMessage("Hello", "a", "b", "c", "d")
+	message.Recipients = []testRecipient{
+		{Name: "Sarah", Role: "to", Status: "read"},
+		{Name: "John", Role: "cc", Status: "unread"},
+		{Name: "Kyle", Role: "cc", Status: "read"},
+	}
+	collection := getTestMessages(t, message)
+
+	// Load: the last two tags, and the first "cc" recipient
+	loaded := testMessage{}
+	err := collection.Load(exp.All(), &loaded, SliceField("tags", -2), ElemMatchField("recipients", exp.Equal("role", "cc")))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", loaded.Subject)
+	assert.Equal(t, []string{"c", "d"}, loaded.Tags)
+	require.Len(t, loaded.Recipients, 1)
+	assert.Equal(t, "John", loaded.Recipients[0].Name)
+
+	// Query: a range of tags
+	results := make([]testMessage, 0)
+	require.NoError(t, collection.Query(&results, exp.All(), SliceFieldRange("tags", 1, 2)))
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{"b", "c"}, results[0].Tags)
+	assert.Len(t, results[0].Recipients, 3)
+
+	// Iterator: excluded fields
+	iterator, err := collection.Iterator(exp.All(), ExcludeFields("recipients"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = iterator.Close() })
+
+	next := testMessage{}
+	require.True(t, iterator.Next(&next))
+	assert.Equal(t, "Hello", next.Subject)
+	assert.Empty(t, next.Recipients)
+	assert.Len(t, next.Tags, 4)
+}
+
 /******************************************
  * Count()
  ******************************************/
@@ -255,6 +591,70 @@ func TestCollection_Query_Sorted(t *testing.T) {
 	assert.Equal(t, 45, results[2].Age)
 }
 
+// A second sort option breaks ties in the first.
+func TestCollection_Query_SortedTieBreak(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("Kyle Reese", 30),
+		newTestPerson("John Connor", 20),
+		newTestPerson("Sarah Connor", 30),
+		newTestPerson("Miles Dyson", 20),
+	)
+
+	results := make([]testPerson, 0)
+	err := collection.Query(&results, exp.All(), option.SortAsc("age"), option.SortDesc("name"))
+
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, "Miles Dyson", results[0].Name)
+	assert.Equal(t, "John Connor", results[1].Name)
+	assert.Equal(t, "Sarah Connor", results[2].Name)
+	assert.Equal(t, "Kyle Reese", results[3].Name)
+
+	// The Iterator uses the same compound sort
+	iterator, err := collection.Iterator(exp.All(), option.SortDesc("age"), option.SortAsc("name"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = iterator.Close() })
+
+	person := testPerson{}
+	require.True(t, iterator.Next(&person))
+	assert.Equal(t, "Kyle Reese", person.Name)
+}
+
+// Sort options choose which document Load returns when several match.
+func TestCollection_Load_Sorted(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("John Connor", 20),
+		newTestPerson("Miles Dyson", 20),
+		newTestPerson("Sarah Connor", 45),
+	)
+
+	person := testPerson{}
+	require.NoError(t, collection.Load(exp.All(), &person, option.SortAsc("age"), option.SortDesc("name")))
+	assert.Equal(t, "Miles Dyson", person.Name)
+}
+
+// Hints must name an existing index, and comments do not change the results.
+func TestCollection_Query_HintAndComment(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("Sarah Connor", 45))
+
+	results := make([]testPerson, 0)
+	require.NoError(t, collection.Query(&results, exp.All(), Hint("_id_"), CallerComment()))
+	assert.Len(t, results, 1)
+
+	count, err := collection.Count(exp.All(), HintKeys(bson.D{{Key: "_id", Value: 1}}), Comment("counting"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	err = collection.Query(&results, exp.All(), Hint("missing_index"))
+	assert.Error(t, err)
+}
+
 func TestCollection_Query_MaxRows(t *testing.T) {
 
 	collection := getTestCollection(t)
@@ -377,91 +777,1027 @@ func TestCollection_Query_StringMatchCaseInsensitive(t *testing.T) {
 	assert.ElementsMatch(t, []string{"John Connor"}, names)
 }
 
-/******************************************
- * Delete() - Virtual
- ******************************************/
-
-func TestCollection_Delete(t *testing.T) {
+// Not and Nor exclude the documents that their expressions match.
+func TestCollection_Query_Negation(t *testing.T) {
 
 	collection := getTestCollection(t)
-	person := newTestPerson("John Connor", 20)
-	seedPeople(t, collection, person)
-
-	err := collection.Delete(person, "deleting")
-	require.NoError(t, err)
-
-	// Virtual delete marks the in-memory object as deleted...
-	assert.True(t, person.IsDeleted())
-
-	// ...and the record still exists in the database, but flagged as deleted.
-	loaded := testPerson{}
-	err = collection.Load(exp.Equal("name", "John Connor"), &loaded)
-	require.NoError(t, err)
-	assert.True(t, loaded.IsDeleted())
-}
+	seedPeople(t, collection,
+		newTestPerson("John", 1),
+		newTestPerson("Sarah", 2),
+		newTestPerson("Kyle", 3),
+	)
 
-// A brand-new object cannot be deleted (there is nothing in the database yet).
-func TestCollection_Delete_NewObject(t *testing.T) {
+	names := queryNames(t, collection, Not(exp.Equal("name", "John")))
+	assert.ElementsMatch(t, []string{"Sarah", "Kyle"}, names)
 
-	collection := getTestCollection(t)
-	person := newTestPerson("Never Saved", 99)
+	names = queryNames(t, collection, Not(exp.Equal("name", "John").OrEqual("name", "Sarah")))
+	assert.ElementsMatch(t, []string{"Kyle"}, names)
 
-	err := collection.Delete(person, "deleting")
-	require.Error(t, err)
+	names = queryNames(t, collection, Nor(exp.Equal("name", "John"), exp.Equal("name", "Kyle")))
+	assert.ElementsMatch(t, []string{"Sarah"}, names)
 }
 
-// When the underlying Save fails, Delete wraps the error while preserving both
-// the 500 status code and the original error in the chain.
-func TestCollection_Delete_SaveError(t *testing.T) {
+// Criteria that cannot be translated are refused, so a typo can never delete
+// every document.
+func TestCollection_HardDelete_InvalidCriteria(t *testing.T) {
 
 	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("John", 1), newTestPerson("Sarah", 2))
 
-	object := &badIDObject{}
-	object.markOld() // not new => Delete proceeds to Save, which fails on the bad ID
+	err := collection.HardDelete(unknownExpression{})
+	assert.True(t, derp.IsBadRequest(err))
 
-	err := collection.Delete(object, "deleting")
+	err = collection.HardDelete(exp.New("name", "unknown-operator", "John"))
+	assert.True(t, derp.IsBadRequest(err))
 
-	require.Error(t, err)
-	assert.Equal(t, http.StatusInternalServerError, derp.ErrorCode(err)) // status preserved
+	err = collection.HardDelete(exp.Contains("name", 42))
+	assert.True(t, derp.IsBadRequest(err))
 
-	// The original driver error is still reachable through the chain (Delete ->
-	// Save -> ObjectIDFromHex).  Before the H2 fix it was flattened to a string.
-	assert.ErrorIs(t, err, primitive.ErrInvalidHex)
+	count, err := collection.Count(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
 }
 
 /******************************************
- * HardDelete()
+ * Update()
  ******************************************/
 
-func TestCollection_HardDelete(t *testing.T) {
+func TestCollection_Update(t *testing.T) {
 
 	collection := getTestCollection(t)
-	seedPeople(t, collection,
-		newTestPerson("John Connor", 20),
-		newTestPerson("Sarah Connor", 45),
-	)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person, newTestPerson("Sarah Connor", 45))
 
-	// Physically remove a single matching record.
-	err := collection.HardDelete(exp.Equal("name", "John Connor"))
-	require.NoError(t, err)
+	changes := Changes{}.Set("age", 21)
+	result, err := collection.Update(exp.Equal("_id", person.PersonID), changes, "birthday")
 
-	count, err := collection.Count(exp.All())
 	require.NoError(t, err)
-	assert.Equal(t, int64(1), count)
+	assert.Equal(t, int64(1), result.MatchedCount)
+	assert.Equal(t, int64(1), result.ModifiedCount)
 
-	// The removed record is truly gone (not a virtual delete).
-	err = collection.Load(exp.Equal("name", "John Connor"), &testPerson{})
-	require.Error(t, err)
-	assert.True(t, derp.IsNotFound(err))
+	// Only the changed field is modified, and the journal is stamped.
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &loaded))
+	assert.Equal(t, 21, loaded.Age)
+	assert.Equal(t, "John Connor", loaded.Name)
+	assert.Equal(t, "birthday", loaded.Note)
+	assert.Equal(t, person.Revision+1, loaded.Revision)
+	assert.GreaterOrEqual(t, loaded.UpdateDate, person.UpdateDate)
 }
 
-func TestCollection_HardDelete_All(t *testing.T) {
+// Two updates to different fields of the same document do not clobber each other.
+func TestCollection_Update_DifferentFields(t *testing.T) {
 
 	collection := getTestCollection(t)
-	seedPeople(t, collection,
-		newTestPerson("John Connor", 20),
-		newTestPerson("Sarah Connor", 45),
-	)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	criteria := exp.Equal("_id", person.PersonID)
+
+	_, err := collection.Update(criteria, Changes{}.Set("age", 21), "")
+	require.NoError(t, err)
+
+	_, err = collection.Update(criteria, Changes{}.Set("name", "John"), "")
+	require.NoError(t, err)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(criteria, &loaded))
+	assert.Equal(t, 21, loaded.Age)
+	assert.Equal(t, "John", loaded.Name)
+}
+
+func TestCollection_Update_UnsetAndInc(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	criteria := exp.Equal("_id", person.PersonID)
+	result, err := collection.Update(criteria, Changes{}.Unset("name").Inc("age", 5), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(criteria, &loaded))
+	assert.Equal(t, "", loaded.Name)
+	assert.Equal(t, 25, loaded.Age)
+}
+
+// Updates apply to every matching document, and report the counts.
+func TestCollection_Update_Many(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("John Connor", 20),
+		newTestPerson("Sarah Connor", 45),
+		newTestPerson("Kyle Reese", 30),
+	)
+
+	result, err := collection.Update(exp.GreaterOrEqual("age", 30), Changes{}.Set("age", 99), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.MatchedCount)
+	assert.Equal(t, int64(2), result.ModifiedCount)
+
+	count, err := collection.Count(exp.Equal("age", 99))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCollection_Update_NoMatch(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	result, err := collection.Update(exp.Equal("name", "Nobody"), Changes{}.Set("age", 1), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.MatchedCount)
+	assert.Equal(t, int64(0), result.ModifiedCount)
+}
+
+// An empty change set is rejected before reaching the database.
+func TestCollection_Update_NoChanges(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	_, err := collection.Update(exp.All(), Changes{}, "")
+
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+// A change set that the server rejects is reported as a 400.
+func TestCollection_Update_InvalidChanges(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("John Connor", 20))
+
+	_, err := collection.Update(exp.All(), Changes{}.Inc("name", "not a number"), "")
+
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+/******************************************
+ * Push() / Pull() / AddToSet()
+ ******************************************/
+
+func TestCollection_Push(t *testing.T) {
+
+	message := newTestMessage("Hello", "a", "b")
+	collection := getTestMessages(t, message)
+	criteria := exp.Equal("_id", message.MessageID)
+
+	result, err := collection.Push(criteria, "tags", []any{"c", "d"}, PushModifiers{}, "tagged")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+
+	loaded := loadTestMessage(t, collection, message.MessageID)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, loaded.Tags)
+	assert.Equal(t, "tagged", loaded.Note)
+}
+
+// $position inserts values at an index, and $slice caps the array length.
+func TestCollection_Push_Modifiers(t *testing.T) {
+
+	message := newTestMessage("Hello", "a", "b", "c")
+	collection := getTestMessages(t, message)
+	criteria := exp.Equal("_id", message.MessageID)
+
+	_, err := collection.Push(criteria, "tags", []any{"z"}, PushModifiers{Position: pointerTo(0), Slice: pointerTo(3)}, "")
+	require.NoError(t, err)
+
+	loaded := loadTestMessage(t, collection, message.MessageID)
+	assert.Equal(t, []string{"z", "a", "b"}, loaded.Tags)
+}
+
+// Pull removes sub-documents that match every sub-predicate.
+func TestCollection_Pull(t *testing.T) {
+
+	message := newTestMessage("Hello")
+	message.Recipients = []testRecipient{
+		{Name: "John", Role: "to", Status: "bounced"},
+		{Name: "Sarah", Role: "bcc", Status: "bounced"},
+		{Name: "Kyle", Role: "bcc", Status: "delivered"},
+	}
+	collection := getTestMessages(t, message)
+
+	match := exp.Equal("role", "bcc").AndEqual("status", "bounced")
+	result, err := collection.Pull(exp.Equal("_id", message.MessageID), "recipients", match, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+
+	loaded := loadTestMessage(t, collection, message.MessageID)
+	require.Len(t, loaded.Recipients, 2)
+	assert.Equal(t, "John", loaded.Recipients[0].Name)
+	assert.Equal(t, "Kyle", loaded.Recipients[1].Name)
+}
+
+// Pull with an empty field name removes matching scalar elements.
+func TestCollection_Pull_Scalar(t *testing.T) {
+
+	message := newTestMessage("Hello", "apple", "banana", "avocado")
+	collection := getTestMessages(t, message)
+
+	_, err := collection.Pull(exp.Equal("_id", message.MessageID), "tags", exp.BeginsWith("", "a"), "")
+	require.NoError(t, err)
+
+	loaded := loadTestMessage(t, collection, message.MessageID)
+	assert.Equal(t, []string{"banana"}, loaded.Tags)
+}
+
+// An untranslatable match expression is refused before anything is pulled.
+func TestCollection_Pull_InvalidMatch(t *testing.T) {
+
+	_, err := Collection{}.Pull(exp.Equal("_id", "123"), "tags", exp.New("", "unknown-operator", "a"), "")
+	assert.True(t, derp.IsBadRequest(err))
+
+}
+
+// ElemMatch requires a single element to satisfy every sub-predicate, while
+// dotted predicates can be satisfied by different elements.
+func TestCollection_Query_ElemMatch(t *testing.T) {
+
+	mixed := newTestMessage("Mixed")
+	mixed.Recipients = []testRecipient{
+		{Name: "John", Role: "to", Status: "sent"},
+		{Name: "Sarah", Role: "bcc", Status: "bounced"},
+	}
+
+	bounced := newTestMessage("Bounced")
+	bounced.Recipients = []testRecipient{
+		{Name: "Kyle", Role: "to", Status: "bounced"},
+	}
+
+	collection := getTestMessages(t, mixed, bounced)
+
+	// subjects returns the subjects of every message that matches the criteria
+	subjects := func(criteria exp.Expression) []string {
+		messages := []testMessage{}
+		require.NoError(t, collection.Query(&messages, criteria))
+
+		result := make([]string, 0, len(messages))
+		for _, message := range messages {
+			result = append(result, message.Subject)
+		}
+		return result
+	}
+
+	dotted := exp.Equal("recipients.role", "to").AndEqual("recipients.status", "bounced")
+	assert.ElementsMatch(t, []string{"Mixed", "Bounced"}, subjects(dotted))
+
+	elemMatch := ElemMatch("recipients", exp.Equal("role", "to").AndEqual("status", "bounced"))
+	assert.ElementsMatch(t, []string{"Bounced"}, subjects(elemMatch))
+
+	assert.ElementsMatch(t, []string{"Mixed"}, subjects(exp.Equal("subject", "Mixed").And(Not(elemMatch))))
+}
+
+// Size, Type, Mod and Regex predicates run against the database.
+func TestCollection_Query_Operators(t *testing.T) {
+
+	none := newTestMessage("None")
+	two := newTestMessage("Two", "a", "b")
+	three := newTestMessage("Three", "a", "b", "c")
+
+	collection := getTestMessages(t, none, two, three)
+
+	// subjects returns the subjects of every message that matches the criteria
+	subjects := func(criteria exp.Expression) []string {
+		messages := []testMessage{}
+		require.NoError(t, collection.Query(&messages, criteria))
+
+		result := make([]string, 0, len(messages))
+		for _, message := range messages {
+			result = append(result, message.Subject)
+		}
+		return result
+	}
+
+	assert.ElementsMatch(t, []string{"Two"}, subjects(Size("tags", 2)))
+	assert.ElementsMatch(t, []string{"Two", "Three"}, subjects(Type("tags.0", "string")))
+	assert.ElementsMatch(t, []string{"Two", "Three"}, subjects(Regex("subject", "^T(wo|hree)$")))
+	assert.ElementsMatch(t, []string{"Three"}, subjects(Regex("subject", primitive.Regex{Pattern: "^three", Options: "i"})))
+
+	seedPeople(t, collection, newTestPerson("John", 20), newTestPerson("Sarah", 21), newTestPerson("Kyle", 22))
+	assert.ElementsMatch(t, []string{"John", "Kyle"}, queryNames(t, collection, Mod("age", 2, 0)))
+
+	// Unsafe patterns are refused before the query runs
+	err := collection.Query(&[]testMessage{}, Regex("subject", "(a+)+$"))
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+func TestCollection_AddToSet(t *testing.T) {
+
+	message := newTestMessage("Hello", "a", "b")
+	collection := getTestMessages(t, message)
+
+	_, err := collection.AddToSet(exp.Equal("_id", message.MessageID), "tags", []any{"b", "c", "c"}, "")
+	require.NoError(t, err)
+
+	loaded := loadTestMessage(t, collection, message.MessageID)
+	assert.Equal(t, []string{"a", "b", "c"}, loaded.Tags)
+}
+
+/******************************************
+ * Increment()
+ ******************************************/
+
+func TestCollection_Increment(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+	criteria := exp.Equal("_id", person.PersonID)
+
+	// Without a ReturnDocument option, no values are returned.
+	values, err := collection.Increment(criteria, map[string]any{"age": 2, "stats.views": 1})
+	require.NoError(t, err)
+	assert.Nil(t, values)
+
+	// ReturnAfter returns the new values, including nested fields.
+	values, err = collection.Increment(criteria, map[string]any{"age": 1, "stats.views": 1}, ReturnAfter())
+	require.NoError(t, err)
+	assert.EqualValues(t, 23, values["age"])
+	assert.EqualValues(t, 2, values["stats.views"])
+
+	// ReturnBefore returns the previous values.
+	values, err = collection.Increment(criteria, map[string]any{"age": -3}, ReturnBefore())
+	require.NoError(t, err)
+	assert.EqualValues(t, 23, values["age"])
+
+	// Counters do not stamp the journal.
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(criteria, &loaded))
+	assert.Equal(t, 20, loaded.Age)
+	assert.Equal(t, person.Revision, loaded.Revision)
+}
+
+// A missing document is a 404, unless the Upsert option is used.
+func TestCollection_Increment_Upsert(t *testing.T) {
+
+	collection := getTestCollection(t)
+	criteria := exp.Equal("name", "counter")
+
+	_, err := collection.Increment(criteria, map[string]any{"age": 5})
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+
+	_, err = collection.Increment(criteria, map[string]any{"age": 5}, ReturnAfter())
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+
+	// The first upsert starts the counter at the delta.
+	values, err := collection.Increment(criteria, map[string]any{"age": 5}, Upsert(), ReturnAfter())
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, values["age"])
+
+	// ReturnBefore on a new document has no previous values.
+	values, err = collection.Increment(exp.Equal("name", "other"), map[string]any{"age": 5}, Upsert(), ReturnBefore())
+	require.NoError(t, err)
+	assert.Nil(t, values["age"])
+
+	_, err = collection.Increment(criteria, map[string]any{"age": 5}, Upsert())
+	require.NoError(t, err)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(criteria, &loaded))
+	assert.Equal(t, 10, loaded.Age)
+
+	// Upserted documents are journaled as created, in every path
+	assert.False(t, loaded.IsNew())
+	assert.Equal(t, loaded.CreateDate, loaded.UpdateDate)
+
+	require.NoError(t, collection.Load(exp.Equal("name", "other"), &loaded))
+	assert.False(t, loaded.IsNew())
+}
+
+func TestCollection_Increment_Errors(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("John Connor", 20))
+
+	// At least one field is required.
+	_, err := collection.Increment(exp.All(), map[string]any{})
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+
+	// Non-numeric fields cannot be incremented.
+	_, err = collection.Increment(exp.All(), map[string]any{"name": 1})
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+
+	_, err = collection.Increment(exp.All(), map[string]any{"name": 1}, ReturnAfter())
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+/******************************************
+ * FindOneAndUpdate()
+ ******************************************/
+
+// FindOneAndUpdate claims the first matching document exactly once, returning
+// the document as it was before the change by default.
+func TestCollection_FindOneAndUpdate(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("John Connor", 20),
+		newTestPerson("Kyle Reese", 30),
+	)
+
+	unclaimed := exp.GreaterThan("age", 0)
+	claim := Changes{}.Set("age", 0)
+
+	// The youngest person is claimed first.
+	claimed := testPerson{}
+	require.NoError(t, collection.FindOneAndUpdate(unclaimed, claim, &claimed, "claimed", option.SortAsc("age")))
+	assert.Equal(t, "John Connor", claimed.Name)
+	assert.Equal(t, 20, claimed.Age) // before the change
+
+	// The next claim gets the next person.
+	require.NoError(t, collection.FindOneAndUpdate(unclaimed, claim, &claimed, "claimed", option.SortAsc("age")))
+	assert.Equal(t, "Kyle Reese", claimed.Name)
+
+	// Nothing is left to claim.
+	err := collection.FindOneAndUpdate(unclaimed, claim, &claimed, "claimed")
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+// ReturnAfter returns the modified document, including the stamped journal.
+func TestCollection_FindOneAndUpdate_ReturnAfter(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	updated := testPerson{}
+	err := collection.FindOneAndUpdate(exp.Equal("_id", person.PersonID), Changes{}.Inc("age", 1), &updated, "birthday", ReturnAfter())
+	require.NoError(t, err)
+
+	assert.Equal(t, 21, updated.Age)
+	assert.Equal(t, "birthday", updated.Note)
+	assert.Equal(t, person.Revision+1, updated.Revision)
+}
+
+// With the Upsert option, a missing document is inserted and journaled as
+// created, instead of a 404.
+func TestCollection_FindOneAndUpdate_Upsert(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	// ReturnBefore has no previous version to return
+	before := testPerson{}
+	err := collection.FindOneAndUpdate(exp.Equal("name", "John Connor"), Changes{}.Set("age", 20), &before, "created", Upsert())
+	require.NoError(t, err)
+	assert.True(t, before.IsNew())
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "John Connor"), &loaded))
+	assert.Equal(t, 20, loaded.Age)
+	assert.False(t, loaded.IsNew())
+	assert.Equal(t, loaded.CreateDate, loaded.UpdateDate)
+	assert.Equal(t, int64(1), loaded.Revision)
+
+	// ReturnAfter returns the inserted document
+	after := testPerson{}
+	err = collection.FindOneAndUpdate(exp.Equal("name", "Sarah Connor"), Changes{}.Set("age", 45), &after, "created", Upsert(), ReturnAfter())
+	require.NoError(t, err)
+	assert.Equal(t, "Sarah Connor", after.Name)
+	assert.False(t, after.IsNew())
+
+	// An existing document keeps its CreateDate
+	createDate := after.CreateDate
+	err = collection.FindOneAndUpdate(exp.Equal("name", "Sarah Connor"), Changes{}.Inc("age", 1), &after, "birthday", Upsert(), ReturnAfter())
+	require.NoError(t, err)
+	assert.Equal(t, 46, after.Age)
+	assert.Equal(t, createDate, after.CreateDate)
+	assert.Equal(t, int64(2), after.Revision)
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCollection_FindOneAndUpdate_NoChanges(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	err := collection.FindOneAndUpdate(exp.All(), Changes{}, &testPerson{}, "")
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+func TestCollection_FindOneAndUpdate_Error(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("John Connor", 20))
+
+	err := collection.FindOneAndUpdate(exp.All(), Changes{}.Inc("name", 1), &testPerson{}, "")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, derp.ErrorCode(err))
+}
+
+/******************************************
+ * FindOneAndDelete()
+ ******************************************/
+
+func TestCollection_FindOneAndDelete(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("John Connor", 20),
+		newTestPerson("Kyle Reese", 30),
+	)
+
+	// The oldest person is removed first.
+	deleted := testPerson{}
+	require.NoError(t, collection.FindOneAndDelete(exp.All(), &deleted, option.SortDesc("age")))
+	assert.Equal(t, "Kyle Reese", deleted.Name)
+
+	assert.ElementsMatch(t, []string{"John Connor"}, queryNames(t, collection, exp.All()))
+}
+
+func TestCollection_FindOneAndDelete_NotFound(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	err := collection.FindOneAndDelete(exp.Equal("name", "Nobody"), &testPerson{})
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+}
+
+/******************************************
+ * Upsert()
+ ******************************************/
+
+// Upserting by a natural key inserts a new document when none matches.
+func TestCollection_Upsert_Insert(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+
+	result, err := collection.Upsert(exp.Equal("name", "John Connor"), person, "sync")
+	require.NoError(t, err)
+	assert.True(t, result.Inserted)
+	assert.Equal(t, int64(0), result.MatchedCount)
+
+	// The object is stamped as created...
+	assert.False(t, person.IsNew())
+
+	// ...and matches the stored document, including its CreateDate.
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "John Connor"), &loaded))
+	assert.Equal(t, person.PersonID, loaded.PersonID)
+	assert.Equal(t, person.Journal, loaded.Journal)
+	assert.False(t, loaded.IsNew())
+	assert.Equal(t, 20, loaded.Age)
+}
+
+// Upserting by a natural key updates the existing document when one matches,
+// keeping its original ID and CreateDate.
+func TestCollection_Upsert_Update(t *testing.T) {
+
+	collection := getTestCollection(t)
+	existing := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, existing)
+
+	// A fresh copy from an external system, with a different ID.
+	incoming := newTestPerson("John Connor", 21)
+
+	result, err := collection.Upsert(exp.Equal("name", "John Connor"), incoming, "sync")
+	require.NoError(t, err)
+	assert.False(t, result.Inserted)
+	assert.Equal(t, int64(1), result.MatchedCount)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "John Connor"), &loaded))
+	assert.Equal(t, existing.PersonID, loaded.PersonID)
+	assert.Equal(t, existing.CreateDate, loaded.CreateDate)
+	assert.Equal(t, 21, loaded.Age)
+	assert.Equal(t, "sync", loaded.Note)
+
+	// The object now matches the stored document...
+	assert.False(t, incoming.IsNew())
+	assert.Equal(t, existing.PersonID, incoming.PersonID)
+	assert.Equal(t, loaded.Journal, incoming.Journal)
+
+	// ...so saving it again updates the same document.
+	incoming.Age = 22
+	require.NoError(t, collection.Save(incoming, "again"))
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+// Updating through Upsert increments the stored revision, instead of copying
+// the incoming object's revision, so that stale copies cannot overwrite it.
+func TestCollection_Upsert_Revision(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	existing := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, existing)
+	require.NoError(t, collection.Save(existing, "second save"))
+
+	stale := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "John Connor"), &stale))
+
+	_, err := collection.Upsert(exp.Equal("name", "John Connor"), newTestPerson("John Connor", 21), "sync")
+	require.NoError(t, err)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "John Connor"), &loaded))
+	assert.Equal(t, stale.Revision+1, loaded.Revision)
+	assert.Equal(t, 21, loaded.Age)
+
+	// The copy loaded before the upsert is now stale
+	stale.Age = 99
+	err = collection.Save(&stale, "stale")
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+}
+
+// Upsert does not revive a virtually-deleted document, and skips it entirely
+// when the Collection excludes deleted documents.
+func TestCollection_Upsert_Deleted(t *testing.T) {
+
+	collection := getTestCollection(t)
+	deleted := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, deleted)
+	require.NoError(t, collection.Delete(deleted, "deleted"))
+
+	// A matching deleted document is updated, but stays deleted
+	_, err := collection.Upsert(exp.Equal("name", "John Connor"), newTestPerson("John Connor", 21), "sync")
+	require.NoError(t, err)
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "John Connor"), &loaded))
+	assert.True(t, loaded.IsDeleted())
+
+	// WithoutDeleted ignores the deleted document and inserts a new one
+	result, err := collection.With(WithoutDeleted()).Upsert(exp.Equal("name", "John Connor"), newTestPerson("John Connor", 22), "sync")
+	require.NoError(t, err)
+	assert.True(t, result.Inserted)
+
+	count, err := collection.Count(exp.Equal("name", "John Connor"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+// Upserting the same object twice inserts it once, then updates it.
+func TestCollection_Upsert_Repeated(t *testing.T) {
+
+	collection := getTestCollection(t)
+	record := &testRecord{RecordID: "https://example.com/records/1", Value: "first"}
+	criteria := exp.Equal("value", "first")
+
+	result, err := collection.Upsert(criteria, record, "sync")
+	require.NoError(t, err)
+	assert.True(t, result.Inserted)
+
+	result, err = collection.Upsert(criteria, record, "sync")
+	require.NoError(t, err)
+	assert.False(t, result.Inserted)
+	assert.Equal(t, int64(1), result.MatchedCount)
+}
+
+// Server errors are reported as a 400.
+func TestCollection_Upsert_Error(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection, newTestPerson("John Connor", 20))
+
+	// An unknown top-level operator in the criteria is rejected by the server.
+	_, err := collection.Upsert(exp.Equal("$bogus", 1), newTestPerson("John Connor", 20), "sync")
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+/******************************************
+ * Delete() - Virtual
+ ******************************************/
+
+func TestCollection_Delete(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	err := collection.Delete(person, "deleting")
+	require.NoError(t, err)
+
+	// Virtual delete marks the in-memory object as deleted...
+	assert.True(t, person.IsDeleted())
+
+	// ...and the record still exists in the database, but flagged as deleted.
+	loaded := testPerson{}
+	err = collection.Load(exp.Equal("name", "John Connor"), &loaded)
+	require.NoError(t, err)
+	assert.True(t, loaded.IsDeleted())
+}
+
+// A brand-new object cannot be deleted (there is nothing in the database yet).
+func TestCollection_Delete_NewObject(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("Never Saved", 99)
+
+	err := collection.Delete(person, "deleting")
+	require.Error(t, err)
+}
+
+// When the underlying Save fails, Delete wraps the error while preserving both
+// the 500 status code and the original error in the chain.
+func TestCollection_Delete_SaveError(t *testing.T) {
+
+	collection := getTestCollection(t)
+
+	object := &badIDObject{}
+	object.markOld() // not new => Delete proceeds to Save, which fails on the bad ID
+
+	err := collection.Delete(object, "deleting")
+
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, derp.ErrorCode(err)) // status preserved
+
+	// The original driver error is still reachable through the chain (Delete ->
+	// Save -> ObjectIDFromHex).  Before the H2 fix it was flattened to a string.
+	assert.ErrorIs(t, err, primitive.ErrInvalidHex)
+}
+
+/******************************************
+ * Restore()
+ ******************************************/
+
+func TestCollection_Restore(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+	require.NoError(t, collection.Delete(person, "deleting"))
+
+	err := collection.Restore(person, "restoring")
+	require.NoError(t, err)
+
+	// The in-memory object is reloaded from the database...
+	assert.False(t, person.IsDeleted())
+	assert.Equal(t, "restoring", person.Note)
+
+	// ...and the stored document is no longer deleted.
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("_id", person.PersonID), &loaded))
+	assert.False(t, loaded.IsDeleted())
+	assert.Equal(t, person.Revision, loaded.Revision)
+
+	// Restored documents are visible to Collections that hide deleted documents.
+	count, err := collection.With(WithoutDeleted()).Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+// Objects that are not deleted cannot be restored.
+func TestCollection_Restore_NotDeleted(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+
+	err := collection.Restore(person, "restoring")
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+// Restoring an object that is missing (or already restored) is a 404.
+func TestCollection_Restore_NotFound(t *testing.T) {
+
+	collection := getTestCollection(t)
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+	require.NoError(t, collection.Delete(person, "deleting"))
+
+	stale := *person
+	require.NoError(t, collection.Restore(person, "first"))
+
+	err := collection.Restore(&stale, "second")
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+}
+
+// With the revision check enabled, a stale copy does not match the stored
+// document, so it is not restored.
+func TestCollection_Restore_RevisionCheck(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithRevisionCheck())
+	person := newTestPerson("John Connor", 20)
+	seedPeople(t, collection, person)
+	require.NoError(t, collection.Delete(person, "deleting"))
+
+	stale := *person
+	stale.Revision = stale.Revision - 1
+
+	err := collection.Restore(&stale, "restoring")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+
+	require.NoError(t, collection.Restore(person, "restoring"))
+
+	// Once restored, the document is no longer found for restoring
+	err = collection.Restore(&stale, "restoring")
+	assert.True(t, derp.IsNotFound(err))
+}
+
+/******************************************
+ * Purge()
+ ******************************************/
+
+func TestCollection_Purge(t *testing.T) {
+
+	collection := getTestCollection(t)
+	active := newTestPerson("John Connor", 20)
+	deleted := newTestPerson("Sarah Connor", 45)
+	seedPeople(t, collection, active, deleted)
+	require.NoError(t, collection.Delete(deleted, "deleting"))
+
+	// Nothing was deleted before this cutoff.
+	count, err := collection.Purge(time.UnixMilli(deleted.DeleteDate))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// The deleted document is purged, but active documents are kept.
+	count, err = collection.Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.ElementsMatch(t, []string{"John Connor"}, queryNames(t, collection, exp.All()))
+}
+
+/******************************************
+ * WithoutDeleted()
+ ******************************************/
+
+// filter leaves the criteria alone unless deleted documents are excluded.
+func TestCollection_Filter(t *testing.T) {
+
+	notDeleted := bson.M{journalDeleteDate: bson.M{"$not": bson.M{"$gt": 0}}}
+	criteria := exp.Equal("name", "John")
+
+	// filter returns the translated criteria, which must be valid
+	filter := func(collection Collection, criteria exp.Expression, options ...option.Option) bson.M {
+		result, err := collection.filter(criteria, options...)
+		require.NoError(t, err)
+		return result
+	}
+
+	collection := Collection{}
+	assert.Equal(t, ExpressionToBSON(criteria), filter(collection, criteria))
+
+	collection = collection.With(WithoutDeleted())
+	assert.Equal(t, bson.M{"$and": bson.A{ExpressionToBSON(criteria), notDeleted}}, filter(collection, criteria))
+
+	// An empty filter is replaced by the "not deleted" predicate alone.
+	assert.Equal(t, notDeleted, filter(collection, exp.All()))
+
+	// IncludeDeleted opts back in for a single query.
+	assert.Equal(t, ExpressionToBSON(criteria), filter(collection, criteria, IncludeDeleted()))
+}
+
+// filter refuses criteria that cannot be translated exactly, instead of
+// running a query that matches more documents than intended.
+func TestCollection_Filter_Invalid(t *testing.T) {
+
+	collection := Collection{}.With(WithoutDeleted())
+
+	result, err := collection.filter(exp.New("name", "unknown-operator", "John"))
+	assert.Nil(t, result)
+	assert.True(t, derp.IsBadRequest(err))
+
+	_, err = collection.filter(unknownExpression{})
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+// seedDeleted saves John (active) and Sarah (virtually deleted), and returns a
+// Collection that excludes deleted documents.
+func seedDeleted(t *testing.T) Collection {
+	t.Helper()
+
+	collection := getTestCollection(t)
+	deleted := newTestPerson("Sarah Connor", 45)
+	seedPeople(t, collection, newTestPerson("John Connor", 20), deleted)
+	require.NoError(t, collection.Delete(deleted, "deleted"))
+
+	return collection.With(WithoutDeleted())
+}
+
+func TestCollection_WithoutDeleted_Count(t *testing.T) {
+
+	collection := seedDeleted(t)
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = collection.Count(exp.All(), IncludeDeleted())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCollection_WithoutDeleted_Query(t *testing.T) {
+
+	collection := seedDeleted(t)
+
+	assert.ElementsMatch(t, []string{"John Connor"}, queryNames(t, collection, exp.All()))
+	assert.ElementsMatch(t, []string{"John Connor"}, queryNames(t, collection, exp.Contains("name", "Connor")))
+
+	results := make([]testPerson, 0)
+	require.NoError(t, collection.Query(&results, exp.All(), IncludeDeleted()))
+	assert.Len(t, results, 2)
+}
+
+func TestCollection_WithoutDeleted_Load(t *testing.T) {
+
+	collection := seedDeleted(t)
+
+	err := collection.Load(exp.Equal("name", "Sarah Connor"), &testPerson{})
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+
+	loaded := testPerson{}
+	require.NoError(t, collection.Load(exp.Equal("name", "Sarah Connor"), &loaded, IncludeDeleted()))
+	assert.True(t, loaded.IsDeleted())
+}
+
+func TestCollection_WithoutDeleted_Iterator(t *testing.T) {
+
+	collection := seedDeleted(t)
+
+	iterator, err := collection.Iterator(exp.All())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = iterator.Close() })
+
+	person := testPerson{}
+	require.True(t, iterator.Next(&person))
+	assert.Equal(t, "John Connor", person.Name)
+	assert.False(t, iterator.Next(&person))
+}
+
+func TestCollection_WithoutDeleted_Update(t *testing.T) {
+
+	collection := seedDeleted(t)
+
+	result, err := collection.Update(exp.All(), Changes{}.Set("age", 99), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.MatchedCount)
+}
+
+// Documents without a journal are not considered deleted.
+func TestCollection_WithoutDeleted_NoJournal(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithoutDeleted())
+
+	_, err := collection.Mongo().InsertOne(context.Background(), bson.M{"name": "No Journal"})
+	require.NoError(t, err)
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+/******************************************
+ * HardDelete()
+ ******************************************/
+
+func TestCollection_HardDelete(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("John Connor", 20),
+		newTestPerson("Sarah Connor", 45),
+	)
+
+	// Physically remove a single matching record.
+	err := collection.HardDelete(exp.Equal("name", "John Connor"))
+	require.NoError(t, err)
+
+	count, err := collection.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// The removed record is truly gone (not a virtual delete).
+	err = collection.Load(exp.Equal("name", "John Connor"), &testPerson{})
+	require.Error(t, err)
+	assert.True(t, derp.IsNotFound(err))
+}
+
+func TestCollection_HardDelete_All(t *testing.T) {
+
+	collection := getTestCollection(t)
+	seedPeople(t, collection,
+		newTestPerson("John Connor", 20),
+		newTestPerson("Sarah Connor", 45),
+	)
 
 	err := collection.HardDelete(exp.All())
 	require.NoError(t, err)
@@ -527,5 +1863,8 @@ func TestCollection_SlowQueryLogging(t *testing.T) {
 	person := newTestPerson("Sarah Connor", 45)
 	require.NoError(t, collection.Save(person, "slow save"))
 	require.NoError(t, collection.Delete(person, "slow delete"))
+	require.NoError(t, collection.Restore(person, "slow restore"))
+	_, err = collection.Purge(time.Now())
+	require.NoError(t, err)
 	require.NoError(t, collection.HardDelete(exp.Equal("name", "Sarah Connor")))
 }
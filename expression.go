@@ -1,18 +1,49 @@
 package mongodb
 
 import (
+	"reflect"
 	"regexp"
+	"slices"
 
+	"github.com/benpate/derp"
 	"github.com/benpate/exp"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// ExpressionToBSON converts a data.Expression value into pure bson.
+// ExpressionToBSON converts a data.Expression value into pure bson.  A nil
+// expression matches every document.  Expressions that cannot be translated
+// exactly are reported, and produce a filter that matches NO documents, so
+// that a mistake can never widen a query.  Use TranslateExpression to receive
+// the error instead.
 func ExpressionToBSON(criteria exp.Expression) bson.M {
 
+	result, err := TranslateExpression(criteria)
+
+	if err != nil {
+		derp.Report(derp.Wrap(err, "data-mongo.ExpressionToBSON", "Untranslatable expression.  No documents will match"))
+		return matchNothing()
+	}
+
+	return result
+}
+
+// TranslateExpression converts a data.Expression value into pure bson, and
+// returns a 400 error if any part of it cannot be translated exactly: an
+// unsupported expression type or operator, or a value that the operator
+// cannot use.  A nil or empty expression matches every document.
+func TranslateExpression(criteria exp.Expression) (bson.M, error) {
+
+	const location = "data-mongo.TranslateExpression"
+
 	switch c := criteria.(type) {
 
+	case nil:
+		return bson.M{}, nil
+
+	case exp.EmptyExpression:
+		return bson.M{}, nil
+
 	case exp.Predicate:
 
 		switch c.Field {
@@ -23,77 +54,300 @@ func ExpressionToBSON(criteria exp.Expression) bson.M {
 				"$text": bson.M{
 					"$search": c.Value,
 				},
-			}
+			}, nil
 
 		default:
-			result := bson.M{}
-			result[c.Field] = operatorBSON(c.Operator, c.Value)
-			return result
+			operator, err := operatorBSON(c.Operator, c.Value)
+
+			if err != nil {
+				return nil, derp.Wrap(err, location, "Translating predicate", c.Field)
+			}
+
+			return bson.M{c.Field: operator}, nil
 		}
 
 	case exp.AndExpression:
 
 		if len(c) == 0 {
-			return nil
+			return nil, nil
 		}
 
-		array := bson.A{}
+		array, err := expressionsToBSON(c)
 
-		for _, exp := range c {
-			array = append(array, ExpressionToBSON(exp))
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Translating AND expression")
 		}
 
-		return bson.M{"$and": array}
+		return bson.M{"$and": array}, nil
 
 	case exp.OrExpression:
 
 		if len(c) == 0 {
-			return nil
+			return nil, nil
+		}
+
+		array, err := expressionsToBSON(c)
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Translating OR expression")
 		}
 
-		array := bson.A{}
+		return bson.M{"$or": array}, nil
 
-		for _, exp := range c {
-			array = append(array, ExpressionToBSON(exp))
+	case NotExpression:
+
+		// MongoDB does not allow $text inside of $nor
+		if hasFullText(c.Expression) {
+			return nil, derp.BadRequest(location, "Full-text search cannot be negated")
+		}
+
+		// Negate a single predicate in place, so that it can still use an index
+		if predicate, ok := c.Expression.(exp.Predicate); ok {
+
+			operator, err := operatorBSON(predicate.Operator, predicate.Value)
+
+			if err != nil {
+				return nil, derp.Wrap(err, location, "Translating NOT predicate", predicate.Field)
+			}
+
+			return bson.M{predicate.Field: bson.M{"$not": operator}}, nil
+		}
+
+		inner, err := expressionToBSON(c.Expression)
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Translating NOT expression")
+		}
+
+		return bson.M{"$nor": bson.A{inner}}, nil
+
+	case NorExpression:
+
+		if len(c) == 0 {
+			return nil, nil
 		}
 
-		return bson.M{"$or": array}
+		// MongoDB does not allow $text inside of $nor
+		if hasFullText(c...) {
+			return nil, derp.BadRequest(location, "Full-text search cannot be negated")
+		}
+
+		array, err := expressionsToBSON(c)
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Translating NOR expression")
+		}
+
+		return bson.M{"$nor": array}, nil
+
+	case ElemMatchExpression:
+
+		if c.Field == "" {
+			return nil, derp.BadRequest(location, "ElemMatch requires an array field")
+		}
+
+		element, err := elemMatchBSON(c.Expression)
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Translating ElemMatch expression", c.Field)
+		}
+
+		return bson.M{c.Field: bson.M{"$elemMatch": element}}, nil
 	}
 
-	return bson.M{}
+	return nil, derp.BadRequest(location, "Unsupported expression type", reflect.TypeOf(criteria).String())
 }
 
-// operatorBSON converts a standard data.Operator into the operators used by mongodb
-func operatorBSON(operator string, value any) bson.M {
+// hasFullText returns TRUE if any of the expressions contains a full-text
+// search predicate, at any depth (including inside of an ElemMatch, whose
+// Fields are qualified by the array field).
+func hasFullText(expressions ...exp.Expression) bool {
+
+	for _, expression := range expressions {
+
+		switch e := expression.(type) {
+
+		case nil:
+
+		case exp.Predicate:
+			if e.Field == "$fullText" {
+				return true
+			}
+
+		case exp.AndExpression:
+			if hasFullText(e...) {
+				return true
+			}
+
+		case exp.OrExpression:
+			if hasFullText(e...) {
+				return true
+			}
+
+		case NorExpression:
+			if hasFullText(e...) {
+				return true
+			}
+
+		case NotExpression:
+			if hasFullText(e.Expression) {
+				return true
+			}
+
+		case ElemMatchExpression:
+			if hasFullText(e.Expression) {
+				return true
+			}
+
+		default:
+			if slices.Contains(e.Fields(), "$fullText") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// expressionsToBSON converts each expression into an element of a bson array
+func expressionsToBSON(expressions []exp.Expression) (bson.A, error) {
+
+	result := make(bson.A, 0, len(expressions))
+
+	for _, expression := range expressions {
+
+		value, err := expressionToBSON(expression)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+// expressionToBSON converts an expression that is nested inside of another
+// expression.  MongoDB requires nested filters to be documents, so an empty
+// expression becomes an empty document instead of nil.
+func expressionToBSON(expression exp.Expression) (bson.M, error) {
+
+	result, err := TranslateExpression(expression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return bson.M{}, nil
+	}
+
+	return result, nil
+}
+
+// elemMatchBSON converts the criteria for a single array element.  Predicates
+// with an empty field name apply to scalar elements directly, and are merged
+// into a single set of operators (such as {"$gt": 5, "$lt": 10}).  Scalar
+// predicates cannot be mixed with sub-document fields.
+func elemMatchBSON(criteria exp.Expression) (bson.M, error) {
+
+	const location = "data-mongo.elemMatchBSON"
+
+	if criteria == nil {
+		return bson.M{}, nil
+	}
+
+	if !slices.Contains(criteria.Fields(), "") {
+		return expressionToBSON(criteria)
+	}
+
+	// Collect the scalar predicates (a single predicate, or an AND of them)
+	var predicates []exp.Expression
+
+	switch c := criteria.(type) {
+	case exp.Predicate:
+		predicates = []exp.Expression{c}
+	case exp.AndExpression:
+		predicates = c
+	}
+
+	result := bson.M{}
+
+	for _, expression := range predicates {
+
+		predicate, ok := expression.(exp.Predicate)
+
+		if !ok || (predicate.Field != "") {
+			return nil, derp.BadRequest(location, "Scalar element criteria must be predicates with an empty field name, joined by AND")
+		}
+
+		operator, err := operatorBSON(predicate.Operator, predicate.Value)
+
+		if err != nil {
+			return nil, derp.Wrap(err, location, "Translating element predicate")
+		}
+
+		for key, value := range operator {
+
+			if _, exists := result[key]; exists {
+				return nil, derp.BadRequest(location, "Scalar element criteria cannot repeat an operator", key)
+			}
+
+			result[key] = value
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, derp.BadRequest(location, "Scalar element criteria must be predicates with an empty field name, joined by AND")
+	}
+
+	return result, nil
+}
+
+// matchNothing returns a filter that matches no documents: the empty filter
+// matches everything, so NOR-ing it matches nothing.  Unlike a condition on a
+// field, this also holds inside $elemMatch and $pull, where the filter is
+// applied to array elements.
+func matchNothing() bson.M {
+	return bson.M{"$nor": bson.A{bson.M{}}}
+}
+
+// operatorBSON converts a standard data.Operator into the operators used by
+// mongodb.  It returns a 400 error for unsupported operators, and for values
+// that the operator cannot use.
+func operatorBSON(operator string, value any) (bson.M, error) {
+
+	const location = "data-mongo.operatorBSON"
 
 	switch operator {
 
 	case exp.OperatorEqual:
-		return bson.M{"$eq": value}
+		return bson.M{"$eq": value}, nil
 
 	case exp.OperatorNotEqual:
-		return bson.M{"$ne": value}
+		return bson.M{"$ne": value}, nil
 
 	case exp.OperatorLessThan:
-		return bson.M{"$lt": value}
+		return bson.M{"$lt": value}, nil
 
 	case exp.OperatorLessOrEqual:
-		return bson.M{"$lte": value}
+		return bson.M{"$lte": value}, nil
 
 	case exp.OperatorGreaterOrEqual:
-		return bson.M{"$gte": value}
+		return bson.M{"$gte": value}, nil
 
 	case exp.OperatorGreaterThan:
-		return bson.M{"$gt": value}
+		return bson.M{"$gt": value}, nil
 
 	case exp.OperatorIn:
-		return bson.M{"$in": value}
+		return bson.M{"$in": value}, nil
 
 	case exp.OperatorNotIn:
-		return bson.M{"$nin": value}
+		return bson.M{"$nin": value}, nil
 
 	case exp.OperatorInAll:
-		return bson.M{"$all": value}
+		return bson.M{"$all": value}, nil
 
 	// The string-matching operators below match a literal substring, so the
 	// value is escaped with regexp.QuoteMeta.  Embedding it raw would let
@@ -102,33 +356,54 @@ func operatorBSON(operator string, value any) bson.M {
 
 	case exp.OperatorBeginsWith:
 		if valueString, isString := value.(string); isString {
-			return bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(valueString), Options: "i"}}
+			return bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(valueString), Options: "i"}}, nil
 		}
 
 	case exp.OperatorContains:
 		if valueString, isString := value.(string); isString {
-			return bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(valueString), Options: "i"}}
+			return bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(valueString), Options: "i"}}, nil
 		}
 
 	case exp.OperatorEndsWith:
 		if valueString, isString := value.(string); isString {
-			return bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(valueString) + "$", Options: "i"}}
+			return bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(valueString) + "$", Options: "i"}}, nil
 		}
 
 	case exp.OperatorExists:
 		if valueBool, isBool := value.(bool); isBool {
-			return bson.M{"$exists": valueBool}
+			return bson.M{"$exists": valueBool}, nil
 		}
 
 	case exp.OperatorGeoWithin:
-		return bson.M{"$geoWithin": bson.M{"$geometry": value}}
+		return bson.M{"$geoWithin": bson.M{"$geometry": value}}, nil
 
 	case exp.OperatorGeoIntersects:
-		return bson.M{"$geoIntersects": bson.M{"$geometry": value}}
+		return bson.M{"$geoIntersects": bson.M{"$geometry": value}}, nil
+
+	case OperatorSize:
+		return sizeBSON(value)
+
+	case OperatorType:
+		return typeBSON(value)
+
+	case OperatorMod:
+		return modBSON(value)
+
+	// Unlike the string-matching operators above, the Regex operator uses the
+	// pattern as-is, so regexBSON checks it according to the current RegexMode.
+
+	case OperatorRegex:
+		regex, err := regexBSON(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return bson.M{"$regex": regex}, nil
 
 	default:
-		return bson.M{"$eq": value}
+		return nil, derp.BadRequest(location, "Unsupported operator", operator)
 	}
 
-	return bson.M{}
+	return nil, derp.BadRequest(location, "Invalid value for operator", operator, value)
 }
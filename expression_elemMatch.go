@@ -0,0 +1,218 @@
+package mongodb
+
+import (
+	"github.com/benpate/exp"
+)
+
+// ElemMatchExpression matches documents where at least one element of an
+// array field satisfies the whole expression.  Unlike predicates on
+// "field.subfield", every sub-predicate must be satisfied by the SAME element.
+type ElemMatchExpression struct {
+	Field      string         // The array field to search
+	Expression exp.Expression // The criteria for a single element, with fields relative to the element
+}
+
+// Compile-time proof that ElemMatchExpression satisfies the exp.Expression interface.
+var _ exp.Expression = ElemMatchExpression{}
+
+// ElemMatch returns an expression that matches documents where at least one
+// element of an array field matches the criteria.  For arrays of
+// sub-documents, criteria fields are relative to each element (such as
+// exp.Equal("role", "to").AndEqual("status", "bounced")).  For arrays of
+// scalar values, use an empty field name to match the elements themselves
+// (such as exp.GreaterThan("", 5)).  It is translated into $elemMatch.
+func ElemMatch(field string, criteria exp.Expression) ElemMatchExpression {
+	return ElemMatchExpression{
+		Field:      field,
+		Expression: criteria,
+	}
+}
+
+// And is a part of the exp.Expression interface.
+// It combines this ElemMatchExpression with another expression into a new AndExpression
+func (e ElemMatchExpression) And(other exp.Expression) exp.Expression {
+
+	if _, ok := other.(exp.EmptyExpression); ok {
+		return e
+	}
+
+	return exp.And(e, other)
+}
+
+// Or is a part of the exp.Expression interface.
+// It combines this ElemMatchExpression with another expression into a new OrExpression
+func (e ElemMatchExpression) Or(other exp.Expression) exp.Expression {
+
+	if _, ok := other.(exp.EmptyExpression); ok {
+		return e
+	}
+
+	return exp.Or(e, other)
+}
+
+// AndEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the Equal comparison
+func (e ElemMatchExpression) AndEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorEqual, value))
+}
+
+// AndNotEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the NotEqual comparison
+func (e ElemMatchExpression) AndNotEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorNotEqual, value))
+}
+
+// AndLessThan is a part of the exp.Expression interface.
+// It creates a new AndExpression using the LessThan comparison
+func (e ElemMatchExpression) AndLessThan(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorLessThan, value))
+}
+
+// AndLessOrEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the LessOrEqual comparison
+func (e ElemMatchExpression) AndLessOrEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorLessOrEqual, value))
+}
+
+// AndGreaterThan is a part of the exp.Expression interface.
+// It creates a new AndExpression using the GreaterThan comparison
+func (e ElemMatchExpression) AndGreaterThan(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorGreaterThan, value))
+}
+
+// AndGreaterOrEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the GreaterOrEqual comparison
+func (e ElemMatchExpression) AndGreaterOrEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorGreaterOrEqual, value))
+}
+
+// AndIn is a part of the exp.Expression interface.
+// It creates a new AndExpression using the In comparison
+func (e ElemMatchExpression) AndIn(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorIn, value))
+}
+
+// AndNotIn is a part of the exp.Expression interface.
+// It creates a new AndExpression using the NotIn comparison
+func (e ElemMatchExpression) AndNotIn(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorNotIn, value))
+}
+
+// AndInAll is a part of the exp.Expression interface.
+// It creates a new AndExpression using the InAll comparison
+func (e ElemMatchExpression) AndInAll(field string, values ...any) exp.Expression {
+	return e.And(exp.New(field, exp.OperatorInAll, values))
+}
+
+// OrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the Equal comparison
+func (e ElemMatchExpression) OrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorEqual, value))
+}
+
+// OrNotEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the NotEqual comparison
+func (e ElemMatchExpression) OrNotEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorNotEqual, value))
+}
+
+// OrLessThan is a part of the exp.Expression interface.
+// It creates a new OrExpression using the LessThan comparison
+func (e ElemMatchExpression) OrLessThan(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorLessThan, value))
+}
+
+// OrLessOrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the LessOrEqual comparison
+func (e ElemMatchExpression) OrLessOrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorLessOrEqual, value))
+}
+
+// OrGreaterThan is a part of the exp.Expression interface.
+// It creates a new OrExpression using the GreaterThan comparison
+func (e ElemMatchExpression) OrGreaterThan(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorGreaterThan, value))
+}
+
+// OrGreaterOrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the GreaterOrEqual comparison
+func (e ElemMatchExpression) OrGreaterOrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorGreaterOrEqual, value))
+}
+
+// OrIn is a part of the exp.Expression interface.
+// It creates a new OrExpression using the In comparison
+func (e ElemMatchExpression) OrIn(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorIn, value))
+}
+
+// OrNotIn is a part of the exp.Expression interface.
+// It creates a new OrExpression using the NotIn comparison
+func (e ElemMatchExpression) OrNotIn(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorNotIn, value))
+}
+
+// OrInAll is a part of the exp.Expression interface.
+// It creates a new OrExpression using the InAll comparison
+func (e ElemMatchExpression) OrInAll(field string, values ...any) exp.Expression {
+	return e.Or(exp.New(field, exp.OperatorInAll, values))
+}
+
+// Match is a part of the exp.Expression interface.
+// The MatcherFunc receives each sub-predicate with its field name qualified by
+// the array field (such as "recipients.role"), so it is responsible for
+// evaluating the predicates against the same element.
+func (e ElemMatchExpression) Match(fn exp.MatcherFunc) bool {
+
+	if e.Expression == nil {
+		return false
+	}
+
+	return e.Expression.Match(func(predicate exp.Predicate) bool {
+		predicate.Field = e.qualify(predicate.Field)
+		return fn(predicate)
+	})
+}
+
+// IsEmpty is a part of the exp.Expression interface.
+// It always returns FALSE, because the array must contain at least one
+// element even when the element criteria are empty.
+func (e ElemMatchExpression) IsEmpty() bool {
+	return false
+}
+
+// NotEmpty is a part of the exp.Expression interface.
+// It always returns TRUE
+func (e ElemMatchExpression) NotEmpty() bool {
+	return true
+}
+
+// Fields is a part of the exp.Expression interface.
+// It returns the fields used in the element criteria, qualified by the array
+// field (such as "recipients.role").
+func (e ElemMatchExpression) Fields() []string {
+
+	if e.Expression == nil {
+		return make([]string, 0)
+	}
+
+	fields := e.Expression.Fields()
+	result := make([]string, len(fields))
+
+	for index, field := range fields {
+		result[index] = e.qualify(field)
+	}
+
+	return result
+}
+
+// qualify returns the full name of a field within an array element.  An empty
+// field name refers to the element itself.
+func (e ElemMatchExpression) qualify(field string) string {
+
+	if field == "" {
+		return e.Field
+	}
+
+	return e.Field + "." + field
+}
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElemMatch_Match(t *testing.T) {
+
+	expression := ElemMatch("recipients", exp.Equal("role", "to").AndEqual("status", "bounced"))
+
+	// Sub-predicates are qualified by the array field
+	assert.True(t, expression.Match(matchValues(map[string]any{"recipients.role": "to", "recipients.status": "bounced"})))
+	assert.False(t, expression.Match(matchValues(map[string]any{"recipients.role": "to", "recipients.status": "sent"})))
+
+	// Scalar predicates refer to the array field itself
+	assert.True(t, ElemMatch("tags", exp.Equal("", "a")).Match(matchValues(map[string]any{"tags": "a"})))
+
+	assert.False(t, ElemMatch("tags", nil).Match(matchValues(nil)))
+}
+
+func TestElemMatch_IsEmpty(t *testing.T) {
+
+	// The array must contain an element, even when the element criteria are empty
+	assert.False(t, ElemMatch("tags", exp.All()).IsEmpty())
+	assert.True(t, ElemMatch("tags", exp.All()).NotEmpty())
+}
+
+func TestElemMatch_Fields(t *testing.T) {
+	assert.Equal(t, []string{"recipients.role", "recipients.status"}, ElemMatch("recipients", exp.Equal("role", "to").AndEqual("status", "bounced")).Fields())
+	assert.Equal(t, []string{"tags"}, ElemMatch("tags", exp.GreaterThan("", 5)).Fields())
+	assert.Equal(t, []string{}, ElemMatch("tags", nil).Fields())
+}
+
+func TestElemMatch_AndOr(t *testing.T) {
+
+	expression := ElemMatch("recipients", exp.Equal("role", "to"))
+
+	assert.Equal(t, exp.AndExpression{expression, exp.Equal("subject", "Hello")}, expression.AndEqual("subject", "Hello"))
+	assert.Equal(t, exp.OrExpression{expression, exp.Equal("subject", "Hello")}, expression.OrEqual("subject", "Hello"))
+	assert.Equal(t, expression, expression.And(exp.Empty()))
+}
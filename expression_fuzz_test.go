@@ -32,13 +32,21 @@ func FuzzOperatorBSON(f *testing.F) {
 		f.Add(exp.OperatorBeginsWith, seed)
 		f.Add(exp.OperatorEndsWith, seed)
 		f.Add(exp.OperatorEqual, seed)
+		f.Add(OperatorRegex, seed)
 		f.Add("totally-unknown-operator", seed)
 	}
 
 	f.Fuzz(func(t *testing.T, operator string, value string) {
 
-		// Invariant 1: translation never panics for any operator/value.
-		result := operatorBSON(operator, value)
+		// Invariant 1: translation never panics for any operator/value, and
+		// either succeeds or returns an error.
+		result, err := operatorBSON(operator, value)
+
+		if err != nil {
+			require.Nil(t, result, "operatorBSON must not return a filter with an error")
+			return
+		}
+
 		require.NotNil(t, result, "operatorBSON must never return a nil map")
 
 		// Invariant 2: for the regex-producing operators, the value must be
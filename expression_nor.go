@@ -0,0 +1,186 @@
+package mongodb
+
+import (
+	"github.com/benpate/exp"
+)
+
+// NorExpression compares a series of sub-expressions, matching documents that
+// match none of them.
+type NorExpression []exp.Expression
+
+// Compile-time proof that NorExpression satisfies the exp.Expression interface.
+var _ exp.Expression = NorExpression{}
+
+// Nor returns an expression that matches every document that matches none of
+// the provided expressions.  It is translated into $nor, which cannot contain
+// a full-text search.
+func Nor(expressions ...exp.Expression) NorExpression {
+	return NorExpression(expressions)
+}
+
+// And is a part of the exp.Expression interface.
+// It combines this NorExpression with another expression into a new AndExpression
+func (e NorExpression) And(other exp.Expression) exp.Expression {
+
+	if _, ok := other.(exp.EmptyExpression); ok {
+		return e
+	}
+
+	return exp.And(e, other)
+}
+
+// Or is a part of the exp.Expression interface.
+// It combines this NorExpression with another expression into a new OrExpression
+func (e NorExpression) Or(other exp.Expression) exp.Expression {
+
+	if _, ok := other.(exp.EmptyExpression); ok {
+		return e
+	}
+
+	return exp.Or(e, other)
+}
+
+// AndEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the Equal comparison
+func (e NorExpression) AndEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorEqual, value))
+}
+
+// AndNotEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the NotEqual comparison
+func (e NorExpression) AndNotEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorNotEqual, value))
+}
+
+// AndLessThan is a part of the exp.Expression interface.
+// It creates a new AndExpression using the LessThan comparison
+func (e NorExpression) AndLessThan(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorLessThan, value))
+}
+
+// AndLessOrEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the LessOrEqual comparison
+func (e NorExpression) AndLessOrEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorLessOrEqual, value))
+}
+
+// AndGreaterThan is a part of the exp.Expression interface.
+// It creates a new AndExpression using the GreaterThan comparison
+func (e NorExpression) AndGreaterThan(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorGreaterThan, value))
+}
+
+// AndGreaterOrEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the GreaterOrEqual comparison
+func (e NorExpression) AndGreaterOrEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorGreaterOrEqual, value))
+}
+
+// AndIn is a part of the exp.Expression interface.
+// It creates a new AndExpression using the In comparison
+func (e NorExpression) AndIn(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorIn, value))
+}
+
+// AndNotIn is a part of the exp.Expression interface.
+// It creates a new AndExpression using the NotIn comparison
+func (e NorExpression) AndNotIn(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorNotIn, value))
+}
+
+// AndInAll is a part of the exp.Expression interface.
+// It creates a new AndExpression using the InAll comparison
+func (e NorExpression) AndInAll(field string, values ...any) exp.Expression {
+	return e.And(exp.New(field, exp.OperatorInAll, values))
+}
+
+// OrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the Equal comparison
+func (e NorExpression) OrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorEqual, value))
+}
+
+// OrNotEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the NotEqual comparison
+func (e NorExpression) OrNotEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorNotEqual, value))
+}
+
+// OrLessThan is a part of the exp.Expression interface.
+// It creates a new OrExpression using the LessThan comparison
+func (e NorExpression) OrLessThan(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorLessThan, value))
+}
+
+// OrLessOrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the LessOrEqual comparison
+func (e NorExpression) OrLessOrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorLessOrEqual, value))
+}
+
+// OrGreaterThan is a part of the exp.Expression interface.
+// It creates a new OrExpression using the GreaterThan comparison
+func (e NorExpression) OrGreaterThan(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorGreaterThan, value))
+}
+
+// OrGreaterOrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the GreaterOrEqual comparison
+func (e NorExpression) OrGreaterOrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorGreaterOrEqual, value))
+}
+
+// OrIn is a part of the exp.Expression interface.
+// It creates a new OrExpression using the In comparison
+func (e NorExpression) OrIn(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorIn, value))
+}
+
+// OrNotIn is a part of the exp.Expression interface.
+// It creates a new OrExpression using the NotIn comparison
+func (e NorExpression) OrNotIn(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorNotIn, value))
+}
+
+// OrInAll is a part of the exp.Expression interface.
+// It creates a new OrExpression using the InAll comparison
+func (e NorExpression) OrInAll(field string, values ...any) exp.Expression {
+	return e.Or(exp.New(field, exp.OperatorInAll, values))
+}
+
+// Match is a part of the exp.Expression interface.
+// It returns TRUE if none of the sub-expressions match
+func (e NorExpression) Match(fn exp.MatcherFunc) bool {
+
+	for _, expression := range e {
+		if expression.Match(fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsEmpty is a part of the exp.Expression interface.
+// It returns TRUE if an expression does not have any sub-expressions
+func (e NorExpression) IsEmpty() bool {
+	return len(e) == 0
+}
+
+// NotEmpty is a part of the exp.Expression interface.
+// It returns TRUE if an expression has one or more sub-expressions
+func (e NorExpression) NotEmpty() bool {
+	return len(e) > 0
+}
+
+// Fields is a part of the exp.Expression interface.
+// It returns a slice of field names that are used in this expression.
+func (e NorExpression) Fields() []string {
+	result := make([]string, 0)
+
+	for _, expression := range e {
+		result = append(result, expression.Fields()...)
+	}
+
+	return result
+}
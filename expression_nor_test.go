@@ -0,0 +1,40 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNor_Match(t *testing.T) {
+
+	expression := Nor(exp.Equal("name", "John"), exp.Equal("name", "Sarah"))
+
+	assert.False(t, expression.Match(matchValues(map[string]any{"name": "John"})))
+	assert.False(t, expression.Match(matchValues(map[string]any{"name": "Sarah"})))
+	assert.True(t, expression.Match(matchValues(map[string]any{"name": "Kyle"})))
+
+	// An empty NOR excludes nothing
+	assert.True(t, Nor().Match(matchValues(nil)))
+}
+
+func TestNor_IsEmpty(t *testing.T) {
+	assert.True(t, Nor().IsEmpty())
+	assert.False(t, Nor().NotEmpty())
+	assert.False(t, Nor(exp.Equal("name", "John")).IsEmpty())
+	assert.True(t, Nor(exp.Equal("name", "John")).NotEmpty())
+}
+
+func TestNor_Fields(t *testing.T) {
+	assert.Equal(t, []string{"name", "age"}, Nor(exp.Equal("name", "John"), exp.GreaterThan("age", 42)).Fields())
+}
+
+func TestNor_AndOr(t *testing.T) {
+
+	expression := Nor(exp.Equal("name", "John"))
+
+	assert.Equal(t, exp.AndExpression{expression, exp.Equal("age", 42)}, expression.AndEqual("age", 42))
+	assert.Equal(t, exp.OrExpression{expression, exp.InAll("tags", "a", "b")}, expression.OrInAll("tags", "a", "b"))
+	assert.Equal(t, expression, expression.And(exp.Empty()))
+}
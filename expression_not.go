@@ -0,0 +1,187 @@
+package mongodb
+
+import (
+	"github.com/benpate/exp"
+)
+
+// NotExpression negates another expression, matching every document that the
+// other expression does not match.
+type NotExpression struct {
+	Expression exp.Expression
+}
+
+// Compile-time proof that NotExpression satisfies the exp.Expression interface.
+var _ exp.Expression = NotExpression{}
+
+// Not returns an expression that matches every document that the provided
+// expression does not match.  A negated predicate is translated into $not, and
+// any other negated expression into $nor.  Full-text searches cannot be
+// negated.
+func Not(expression exp.Expression) NotExpression {
+	return NotExpression{Expression: expression}
+}
+
+// And is a part of the exp.Expression interface.
+// It combines this NotExpression with another expression into a new AndExpression
+func (e NotExpression) And(other exp.Expression) exp.Expression {
+
+	if _, ok := other.(exp.EmptyExpression); ok {
+		return e
+	}
+
+	return exp.And(e, other)
+}
+
+// Or is a part of the exp.Expression interface.
+// It combines this NotExpression with another expression into a new OrExpression
+func (e NotExpression) Or(other exp.Expression) exp.Expression {
+
+	if _, ok := other.(exp.EmptyExpression); ok {
+		return e
+	}
+
+	return exp.Or(e, other)
+}
+
+// AndEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the Equal comparison
+func (e NotExpression) AndEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorEqual, value))
+}
+
+// AndNotEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the NotEqual comparison
+func (e NotExpression) AndNotEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorNotEqual, value))
+}
+
+// AndLessThan is a part of the exp.Expression interface.
+// It creates a new AndExpression using the LessThan comparison
+func (e NotExpression) AndLessThan(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorLessThan, value))
+}
+
+// AndLessOrEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the LessOrEqual comparison
+func (e NotExpression) AndLessOrEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorLessOrEqual, value))
+}
+
+// AndGreaterThan is a part of the exp.Expression interface.
+// It creates a new AndExpression using the GreaterThan comparison
+func (e NotExpression) AndGreaterThan(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorGreaterThan, value))
+}
+
+// AndGreaterOrEqual is a part of the exp.Expression interface.
+// It creates a new AndExpression using the GreaterOrEqual comparison
+func (e NotExpression) AndGreaterOrEqual(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorGreaterOrEqual, value))
+}
+
+// AndIn is a part of the exp.Expression interface.
+// It creates a new AndExpression using the In comparison
+func (e NotExpression) AndIn(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorIn, value))
+}
+
+// AndNotIn is a part of the exp.Expression interface.
+// It creates a new AndExpression using the NotIn comparison
+func (e NotExpression) AndNotIn(name string, value any) exp.Expression {
+	return e.And(exp.New(name, exp.OperatorNotIn, value))
+}
+
+// AndInAll is a part of the exp.Expression interface.
+// It creates a new AndExpression using the InAll comparison
+func (e NotExpression) AndInAll(field string, values ...any) exp.Expression {
+	return e.And(exp.New(field, exp.OperatorInAll, values))
+}
+
+// OrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the Equal comparison
+func (e NotExpression) OrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorEqual, value))
+}
+
+// OrNotEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the NotEqual comparison
+func (e NotExpression) OrNotEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorNotEqual, value))
+}
+
+// OrLessThan is a part of the exp.Expression interface.
+// It creates a new OrExpression using the LessThan comparison
+func (e NotExpression) OrLessThan(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorLessThan, value))
+}
+
+// OrLessOrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the LessOrEqual comparison
+func (e NotExpression) OrLessOrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorLessOrEqual, value))
+}
+
+// OrGreaterThan is a part of the exp.Expression interface.
+// It creates a new OrExpression using the GreaterThan comparison
+func (e NotExpression) OrGreaterThan(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorGreaterThan, value))
+}
+
+// OrGreaterOrEqual is a part of the exp.Expression interface.
+// It creates a new OrExpression using the GreaterOrEqual comparison
+func (e NotExpression) OrGreaterOrEqual(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorGreaterOrEqual, value))
+}
+
+// OrIn is a part of the exp.Expression interface.
+// It creates a new OrExpression using the In comparison
+func (e NotExpression) OrIn(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorIn, value))
+}
+
+// OrNotIn is a part of the exp.Expression interface.
+// It creates a new OrExpression using the NotIn comparison
+func (e NotExpression) OrNotIn(name string, value any) exp.Expression {
+	return e.Or(exp.New(name, exp.OperatorNotIn, value))
+}
+
+// OrInAll is a part of the exp.Expression interface.
+// It creates a new OrExpression using the InAll comparison
+func (e NotExpression) OrInAll(field string, values ...any) exp.Expression {
+	return e.Or(exp.New(field, exp.OperatorInAll, values))
+}
+
+// Match is a part of the exp.Expression interface.
+// It returns TRUE if the negated expression does NOT match
+func (e NotExpression) Match(fn exp.MatcherFunc) bool {
+
+	if e.Expression == nil {
+		return false
+	}
+
+	return !e.Expression.Match(fn)
+}
+
+// IsEmpty is a part of the exp.Expression interface.
+// It always returns FALSE, because a negation restricts the results even when
+// the negated expression is empty.
+func (e NotExpression) IsEmpty() bool {
+	return false
+}
+
+// NotEmpty is a part of the exp.Expression interface.
+// It always returns TRUE
+func (e NotExpression) NotEmpty() bool {
+	return true
+}
+
+// Fields is a part of the exp.Expression interface.
+// It returns a slice of field names that are used in the negated expression.
+func (e NotExpression) Fields() []string {
+
+	if e.Expression == nil {
+		return make([]string, 0)
+	}
+
+	return e.Expression.Fields()
+}
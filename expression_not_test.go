@@ -0,0 +1,51 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+)
+
+// matchValues returns a MatcherFunc that compares predicates with equality
+// against the provided values
+func matchValues(values map[string]any) exp.MatcherFunc {
+	return func(predicate exp.Predicate) bool {
+		return values[predicate.Field] == predicate.Value
+	}
+}
+
+func TestNot_Match(t *testing.T) {
+
+	expression := Not(exp.Equal("name", "John"))
+
+	assert.False(t, expression.Match(matchValues(map[string]any{"name": "John"})))
+	assert.True(t, expression.Match(matchValues(map[string]any{"name": "Sarah"})))
+
+	// Negating nothing matches nothing
+	assert.False(t, Not(nil).Match(matchValues(nil)))
+}
+
+func TestNot_IsEmpty(t *testing.T) {
+
+	// A negation is never empty, even when the negated expression is
+	assert.False(t, Not(exp.All()).IsEmpty())
+	assert.True(t, Not(exp.All()).NotEmpty())
+}
+
+func TestNot_Fields(t *testing.T) {
+	assert.Equal(t, []string{"name", "age"}, Not(exp.Equal("name", "John").AndGreaterThan("age", 42)).Fields())
+	assert.Equal(t, []string{}, Not(nil).Fields())
+}
+
+func TestNot_AndOr(t *testing.T) {
+
+	expression := Not(exp.Equal("name", "John"))
+
+	assert.Equal(t, exp.AndExpression{expression, exp.Equal("age", 42)}, expression.AndEqual("age", 42))
+	assert.Equal(t, exp.OrExpression{expression, exp.Equal("age", 42)}, expression.OrEqual("age", 42))
+
+	// Combining with an empty expression changes nothing
+	assert.Equal(t, expression, expression.And(exp.Empty()))
+	assert.Equal(t, expression, expression.Or(exp.Empty()))
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/benpate/derp"
 	"github.com/benpate/exp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -86,20 +87,244 @@ func TestExpressionToBSON_EmptyOr(t *testing.T) {
 	assert.Nil(t, ExpressionToBSON(exp.Or()))
 }
 
-// An unrecognized expression type falls through to an empty filter.
-func TestExpressionToBSON_UnknownType(t *testing.T) {
+// A nil expression has no criteria, so it matches every document.
+func TestExpressionToBSON_Nil(t *testing.T) {
 	assert.Equal(t, bson.M{}, ExpressionToBSON(nil))
+	assert.Equal(t, bson.M{}, ExpressionToBSON(exp.Empty()))
+}
+
+// unknownExpression is an exp.Expression that ExpressionToBSON does not know
+type unknownExpression struct {
+	exp.EmptyExpression
+}
+
+// An expression that cannot be translated exactly fails closed, matching NO
+// documents instead of every document.
+func TestExpressionToBSON_Invalid(t *testing.T) {
+	assert.Equal(t, matchNothing(), ExpressionToBSON(unknownExpression{}))
+	assert.Equal(t, matchNothing(), ExpressionToBSON(exp.New("name", "unknown-operator", "John")))
+	assert.Equal(t, matchNothing(), ExpressionToBSON(exp.Contains("name", 42)))
+
+	// ...even when only a nested part of the expression is invalid
+	assert.Equal(t, matchNothing(), ExpressionToBSON(exp.Or(exp.Equal("name", "John"), unknownExpression{})))
+}
+
+/******************************************
+ * ExpressionToBSON() - Array Elements
+ ******************************************/
+
+// Every sub-predicate of an ElemMatch must match the same element.
+func TestExpressionToBSON_ElemMatch(t *testing.T) {
+
+	assert.Equal(t,
+		bson.M{"recipients": bson.M{"$elemMatch": bson.M{"$and": bson.A{
+			bson.M{"role": bson.M{"$eq": "to"}},
+			bson.M{"status": bson.M{"$eq": "bounced"}},
+		}}}},
+		ExpressionToBSON(ElemMatch("recipients", exp.Equal("role", "to").AndEqual("status", "bounced"))))
+
+	// Empty criteria match any array with at least one element
+	assert.Equal(t,
+		bson.M{"tags": bson.M{"$elemMatch": bson.M{}}},
+		ExpressionToBSON(ElemMatch("tags", exp.All())))
+}
+
+// Scalar predicates apply to each element directly, and are merged together.
+func TestExpressionToBSON_ElemMatchScalar(t *testing.T) {
+
+	assert.Equal(t,
+		bson.M{"scores": bson.M{"$elemMatch": bson.M{"$gt": 5}}},
+		ExpressionToBSON(ElemMatch("scores", exp.GreaterThan("", 5))))
+
+	assert.Equal(t,
+		bson.M{"scores": bson.M{"$elemMatch": bson.M{"$gt": 5, "$lt": 10}}},
+		ExpressionToBSON(ElemMatch("scores", exp.GreaterThan("", 5).AndLessThan("", 10))))
+}
+
+// ElemMatch can be nested inside of And, Or, Not and other ElemMatch expressions.
+func TestExpressionToBSON_ElemMatchNested(t *testing.T) {
+
+	bounced := ElemMatch("recipients", exp.Equal("role", "to").AndEqual("status", "bounced"))
+	bouncedBSON := ExpressionToBSON(bounced)
+
+	assert.Equal(t,
+		bson.M{"$and": bson.A{bson.M{"subject": bson.M{"$eq": "Hello"}}, bouncedBSON}},
+		ExpressionToBSON(exp.Equal("subject", "Hello").And(bounced)))
+
+	assert.Equal(t,
+		bson.M{"$or": bson.A{bson.M{"subject": bson.M{"$eq": "Hello"}}, bouncedBSON}},
+		ExpressionToBSON(exp.Equal("subject", "Hello").Or(bounced)))
+
+	assert.Equal(t,
+		bson.M{"$nor": bson.A{bouncedBSON}},
+		ExpressionToBSON(Not(bounced)))
+
+	assert.Equal(t,
+		bson.M{"groups": bson.M{"$elemMatch": bson.M{"members": bson.M{"$elemMatch": bson.M{"name": bson.M{"$eq": "John"}}}}}},
+		ExpressionToBSON(ElemMatch("groups", ElemMatch("members", exp.Equal("name", "John")))))
+}
+
+// ElemMatch criteria that cannot be translated exactly are errors.
+func TestTranslateExpression_ElemMatchInvalid(t *testing.T) {
+
+	invalid := []exp.Expression{
+		ElemMatch("", exp.Equal("role", "to")),
+		ElemMatch("recipients", exp.New("role", "unknown-operator", "to")),
+		ElemMatch("scores", exp.GreaterThan("", 5).AndGreaterThan("", 10)),
+		ElemMatch("scores", exp.GreaterThan("", 5).OrLessThan("", 1)),
+		ElemMatch("scores", exp.GreaterThan("", 5).AndEqual("role", "to")),
+		ElemMatch("scores", exp.Contains("", 5)),
+	}
+
+	for index, criteria := range invalid {
+		_, err := TranslateExpression(criteria)
+		assert.True(t, derp.IsBadRequest(err), "index=%d", index)
+	}
+}
+
+/******************************************
+ * TranslateExpression()
+ ******************************************/
+
+// Valid expressions translate exactly as they do with ExpressionToBSON.
+func TestTranslateExpression(t *testing.T) {
+
+	criteria := exp.Equal("name", "John").And(Not(exp.GreaterThan("age", 42)))
+	result, err := TranslateExpression(criteria)
+
+	require.NoError(t, err)
+	assert.Equal(t, ExpressionToBSON(criteria), result)
+
+	result, err = TranslateExpression(nil)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{}, result)
+}
+
+// Every untranslatable part of an expression is a 400 error, wherever it is
+// nested.
+func TestTranslateExpression_Invalid(t *testing.T) {
+
+	invalid := []exp.Expression{
+		unknownExpression{},
+		exp.New("name", "unknown-operator", "John"),
+		exp.New("name", exp.OperatorContainedBy, "John"),
+		exp.BeginsWith("name", 42),
+		exp.New("name", exp.OperatorExists, "yes"),
+		exp.And(exp.Equal("name", "John"), unknownExpression{}),
+		exp.Or(exp.Equal("name", "John"), exp.Contains("name", 42)),
+		Not(exp.New("name", "unknown-operator", "John")),
+		Not(exp.And(exp.Equal("name", "John"), unknownExpression{})),
+		Nor(exp.Equal("name", "John"), unknownExpression{}),
+	}
+
+	for index, criteria := range invalid {
+		result, err := TranslateExpression(criteria)
+		assert.Nil(t, result, "index=%d", index)
+		assert.True(t, derp.IsBadRequest(err), "index=%d", index)
+	}
+}
+
+// Empty expressions nested inside of another expression become empty
+// documents, because MongoDB rejects null filters.
+func TestExpressionToBSON_NestedEmpty(t *testing.T) {
+	assert.Equal(t,
+		bson.M{"$and": bson.A{bson.M{}, bson.M{"age": bson.M{"$gt": 42}}}},
+		ExpressionToBSON(exp.AndExpression{exp.All(), exp.GreaterThan("age", 42)}))
+}
+
+/******************************************
+ * ExpressionToBSON() - Negation
+ ******************************************/
+
+// A negated predicate is negated in place with $not.
+func TestExpressionToBSON_NotPredicate(t *testing.T) {
+
+	assert.Equal(t,
+		bson.M{"age": bson.M{"$not": bson.M{"$gt": 42}}},
+		ExpressionToBSON(Not(exp.GreaterThan("age", 42))))
+
+	assert.Equal(t,
+		bson.M{"name": bson.M{"$not": bson.M{"$regex": primitive.Regex{Pattern: "^John", Options: "i"}}}},
+		ExpressionToBSON(Not(exp.BeginsWith("name", "John"))))
+}
+
+// Any other negated expression is wrapped in $nor.
+func TestExpressionToBSON_NotExpression(t *testing.T) {
+
+	assert.Equal(t,
+		bson.M{"$nor": bson.A{bson.M{"$and": bson.A{
+			bson.M{"name": bson.M{"$eq": "John"}},
+			bson.M{"age": bson.M{"$gt": 42}},
+		}}}},
+		ExpressionToBSON(Not(exp.Equal("name", "John").AndGreaterThan("age", 42))))
+}
+
+// MongoDB does not allow $text inside of $nor, so negated full-text searches
+// are refused before they reach the server.
+func TestTranslateExpression_NegatedFullText(t *testing.T) {
+
+	for _, criteria := range []exp.Expression{
+		Not(exp.Equal("$fullText", "hello")),
+		Not(exp.Equal("name", "John").AndEqual("$fullText", "hello")),
+		Nor(exp.Equal("name", "John"), exp.Equal("$fullText", "hello")),
+		exp.Equal("name", "John").And(Not(exp.Equal("$fullText", "hello"))),
+		Not(ElemMatch("recipients", exp.Equal("$fullText", "hello"))),
+		Nor(ElemMatch("recipients", exp.Equal("role", "bcc").AndEqual("$fullText", "hello"))),
+	} {
+		_, err := TranslateExpression(criteria)
+		assert.True(t, derp.IsBadRequest(err), "criteria=%#v", criteria)
+	}
+}
+
+// Negating an empty expression (which matches everything) matches nothing.
+func TestExpressionToBSON_NotEmpty(t *testing.T) {
+	assert.Equal(t, matchNothing(), ExpressionToBSON(Not(exp.All())))
+	assert.Equal(t, matchNothing(), ExpressionToBSON(Not(nil)))
+}
+
+func TestExpressionToBSON_Nor(t *testing.T) {
+
+	assert.Equal(t,
+		bson.M{"$nor": bson.A{
+			bson.M{"name": bson.M{"$eq": "John"}},
+			bson.M{"$or": bson.A{bson.M{"age": bson.M{"$lt": 18}}, bson.M{"age": bson.M{"$gt": 65}}}},
+		}},
+		ExpressionToBSON(Nor(
+			exp.Equal("name", "John"),
+			exp.LessThan("age", 18).OrGreaterThan("age", 65),
+		)))
+
+	// An empty NOR excludes nothing
+	assert.Nil(t, ExpressionToBSON(Nor()))
+}
+
+// Negations can be nested inside of other expressions.
+func TestExpressionToBSON_NestedNegation(t *testing.T) {
+	assert.Equal(t,
+		bson.M{"$and": bson.A{
+			bson.M{"name": bson.M{"$eq": "John"}},
+			bson.M{"age": bson.M{"$not": bson.M{"$gt": 42}}},
+		}},
+		ExpressionToBSON(exp.Equal("name", "John").And(Not(exp.GreaterThan("age", 42)))))
 }
 
 /******************************************
  * operatorBSON()
  ******************************************/
 
+// mustOperatorBSON returns the translation of an operator that must be valid
+func mustOperatorBSON(t *testing.T, operator string, value any) bson.M {
+	t.Helper()
+	result, err := operatorBSON(operator, value)
+	require.NoError(t, err)
+	return result
+}
+
 func TestOperatorBSON_Comparisons(t *testing.T) {
 
 	// check confirms that a single operator maps to the expected BSON.
 	check := func(operator string, expected bson.M) {
-		assert.Equal(t, expected, operatorBSON(operator, 42), "operator=%s", operator)
+		assert.Equal(t, expected, mustOperatorBSON(t, operator, 42), "operator=%s", operator)
 	}
 
 	check(exp.OperatorEqual, bson.M{"$eq": 42})
@@ -108,32 +333,40 @@ func TestOperatorBSON_Comparisons(t *testing.T) {
 	check(exp.OperatorLessOrEqual, bson.M{"$lte": 42})
 	check(exp.OperatorGreaterOrEqual, bson.M{"$gte": 42})
 	check(exp.OperatorGreaterThan, bson.M{"$gt": 42})
+}
+
+// An unrecognized operator is an error, instead of silently becoming equality.
+func TestOperatorBSON_Unknown(t *testing.T) {
+
+	result, err := operatorBSON("unknown-operator", 42)
+	assert.Nil(t, result)
+	assert.True(t, derp.IsBadRequest(err))
 
-	// An unrecognized operator defaults to equality.
-	check("unknown-operator", bson.M{"$eq": 42})
+	_, err = operatorBSON("", 42)
+	assert.True(t, derp.IsBadRequest(err))
 }
 
 func TestOperatorBSON_Sets(t *testing.T) {
 	values := []any{1, 2, 3}
 
-	assert.Equal(t, bson.M{"$in": values}, operatorBSON(exp.OperatorIn, values))
-	assert.Equal(t, bson.M{"$nin": values}, operatorBSON(exp.OperatorNotIn, values))
-	assert.Equal(t, bson.M{"$all": values}, operatorBSON(exp.OperatorInAll, values))
+	assert.Equal(t, bson.M{"$in": values}, mustOperatorBSON(t, exp.OperatorIn, values))
+	assert.Equal(t, bson.M{"$nin": values}, mustOperatorBSON(t, exp.OperatorNotIn, values))
+	assert.Equal(t, bson.M{"$all": values}, mustOperatorBSON(t, exp.OperatorInAll, values))
 }
 
 func TestOperatorBSON_StringMatching(t *testing.T) {
 
 	assert.Equal(t,
 		bson.M{"$regex": primitive.Regex{Pattern: "^John", Options: "i"}},
-		operatorBSON(exp.OperatorBeginsWith, "John"))
+		mustOperatorBSON(t, exp.OperatorBeginsWith, "John"))
 
 	assert.Equal(t,
 		bson.M{"$regex": primitive.Regex{Pattern: "Connor", Options: "i"}},
-		operatorBSON(exp.OperatorContains, "Connor"))
+		mustOperatorBSON(t, exp.OperatorContains, "Connor"))
 
 	assert.Equal(t,
 		bson.M{"$regex": primitive.Regex{Pattern: "Connor$", Options: "i"}},
-		operatorBSON(exp.OperatorEndsWith, "Connor"))
+		mustOperatorBSON(t, exp.OperatorEndsWith, "Connor"))
 }
 
 // Regex metacharacters in the value are escaped so the operators match a literal
@@ -144,7 +377,7 @@ func TestOperatorBSON_StringMatchingEscapesMetacharacters(t *testing.T) {
 	// the operator's anchor applied OUTSIDE the escaped value.
 	check := func(operator string, value string, expectedPattern string) {
 		expected := bson.M{"$regex": primitive.Regex{Pattern: expectedPattern, Options: "i"}}
-		assert.Equal(t, expected, operatorBSON(operator, value), "operator=%s value=%q", operator, value)
+		assert.Equal(t, expected, mustOperatorBSON(t, operator, value), "operator=%s value=%q", operator, value)
 	}
 
 	// A representative spread of regex metacharacters, plus injection payloads.
@@ -169,19 +402,23 @@ func TestOperatorBSON_StringMatchingEscapesMetacharacters(t *testing.T) {
 }
 
 // The string-matching operators only apply to string values; anything else
-// falls through to an empty filter.
+// is an error.
 func TestOperatorBSON_StringMatchingNonString(t *testing.T) {
-	assert.Equal(t, bson.M{}, operatorBSON(exp.OperatorBeginsWith, 42))
-	assert.Equal(t, bson.M{}, operatorBSON(exp.OperatorContains, 42))
-	assert.Equal(t, bson.M{}, operatorBSON(exp.OperatorEndsWith, 42))
+
+	for _, operator := range []string{exp.OperatorBeginsWith, exp.OperatorContains, exp.OperatorEndsWith} {
+		result, err := operatorBSON(operator, 42)
+		assert.Nil(t, result)
+		assert.True(t, derp.IsBadRequest(err), "operator=%s", operator)
+	}
 }
 
 func TestOperatorBSON_Exists(t *testing.T) {
-	assert.Equal(t, bson.M{"$exists": true}, operatorBSON(exp.OperatorExists, true))
-	assert.Equal(t, bson.M{"$exists": false}, operatorBSON(exp.OperatorExists, false))
+	assert.Equal(t, bson.M{"$exists": true}, mustOperatorBSON(t, exp.OperatorExists, true))
+	assert.Equal(t, bson.M{"$exists": false}, mustOperatorBSON(t, exp.OperatorExists, false))
 
-	// A non-boolean value falls through to an empty filter.
-	assert.Equal(t, bson.M{}, operatorBSON(exp.OperatorExists, "yes"))
+	// A non-boolean value is an error.
+	_, err := operatorBSON(exp.OperatorExists, "yes")
+	assert.True(t, derp.IsBadRequest(err))
 }
 
 func TestOperatorBSON_Geo(t *testing.T) {
@@ -189,11 +426,11 @@ func TestOperatorBSON_Geo(t *testing.T) {
 
 	assert.Equal(t,
 		bson.M{"$geoWithin": bson.M{"$geometry": shape}},
-		operatorBSON(exp.OperatorGeoWithin, shape))
+		mustOperatorBSON(t, exp.OperatorGeoWithin, shape))
 
 	assert.Equal(t,
 		bson.M{"$geoIntersects": bson.M{"$geometry": shape}},
-		operatorBSON(exp.OperatorGeoIntersects, shape))
+		mustOperatorBSON(t, exp.OperatorGeoIntersects, shape))
 }
 
 // A predicate routed through ExpressionToBSON nests the operator under the field.
@@ -20,7 +20,7 @@ func (polygon testPolygon) GeoJSON() map[string]any {
 
 func TestPolygon(t *testing.T) {
 	polygon := testPolygon([][]float64{{1, 2}, {3, 4}, {5, 6}, {7, 8}})
-	actual := operatorBSON(exp.OperatorGeoIntersects, polygon.GeoJSON())
+	actual := mustOperatorBSON(t, exp.OperatorGeoIntersects, polygon.GeoJSON())
 
 	expected := bson.M{
 		"$geoIntersects": primitive.M{
@@ -12,6 +12,17 @@ import (
 type Iterator struct {
 	context context.Context
 	cursor  *mongo.Cursor
+	state   *iteratorState
+}
+
+// iteratorState holds the values that an Iterator updates while it is being
+// read.  It is shared by every copy of the Iterator.
+type iteratorState struct {
+	skipInvalid bool  // If TRUE, documents that cannot be decoded are skipped
+	skipped     int   // The number of documents that have been skipped
+	err         error // The decode error that stopped this Iterator
+	total       int64 // The number of documents that matched the query
+	hasTotal    bool  // If TRUE, the total has been counted
 }
 
 // NewIterator returns a fully populated Iterator object
@@ -19,11 +30,14 @@ func NewIterator(ctx context.Context, cursor *mongo.Cursor) Iterator {
 	return Iterator{
 		context: ctx,
 		cursor:  cursor,
+		state:   &iteratorState{},
 	}
 }
 
-// Count returns the total number of records contained by this iterator.  A
-// cursor-less iterator (for example, one returned alongside an error) is empty.
+// Count returns the number of records remaining in the cursor's current batch,
+// which is NOT the number of records that matched the query.  Use Total for
+// that.  A cursor-less iterator (for example, one returned alongside an error)
+// is empty.
 func (iterator Iterator) Count() int {
 	if iterator.cursor == nil {
 		return 0
@@ -31,23 +45,78 @@ func (iterator Iterator) Count() int {
 	return iterator.cursor.RemainingBatchLength()
 }
 
+// Total returns the number of records that matched the query, ignoring
+// MaxRows.  The second value is FALSE unless the iterator was created with the
+// CountTotal option.
+func (iterator Iterator) Total() (int64, bool) {
+	if iterator.state == nil {
+		return 0, false
+	}
+	return iterator.state.total, iterator.state.hasTotal
+}
+
 // Next populates the next value from the wrapped Cursor, or returns FALSE.  A
-// cursor-less iterator is always exhausted.
+// cursor-less iterator is always exhausted.  If a document cannot be decoded
+// then Next returns FALSE and the decode error is available from Error(),
+// unless the iterator was created with the SkipInvalid option.
 func (iterator Iterator) Next(output any) bool {
 
-	if iterator.cursor == nil {
+	if iterator.state != nil && iterator.state.err != nil {
 		return false
 	}
 
-	if !iterator.cursor.Next(iterator.context) {
+	ok, err := iterator.decodeNext(output)
+
+	if err != nil {
+		if iterator.state != nil {
+			iterator.state.err = err
+		}
 		return false
 	}
 
-	if err := iterator.cursor.Decode(output); err != nil {
-		return false
+	return ok
+}
+
+// decodeNext advances the wrapped Cursor and decodes the current document into
+// output.  It returns FALSE once the cursor is exhausted, and TRUE along with
+// an error if the current document could not be decoded.  When skipping
+// invalid documents, they are counted and reported instead.
+func (iterator Iterator) decodeNext(output any) (bool, error) {
+
+	const location = "data-mongo.Iterator.decodeNext"
+
+	if iterator.cursor == nil {
+		return false, nil
+	}
+
+	for iterator.cursor.Next(iterator.context) {
+
+		err := iterator.cursor.Decode(output)
+
+		if err == nil {
+			return true, nil
+		}
+
+		err = derp.Wrap(err, location, "Decoding document", iterator.cursor.Current.Lookup("_id").String(), derp.WithCode(http.StatusInternalServerError))
+
+		if (iterator.state == nil) || !iterator.state.skipInvalid {
+			return true, err
+		}
+
+		iterator.state.skipped++
+		derp.Report(err)
 	}
 
-	return true
+	return false, nil
+}
+
+// Skipped returns the number of documents that could not be decoded and were
+// skipped.  This is always zero unless the SkipInvalid option is used.
+func (iterator Iterator) Skipped() int {
+	if iterator.state == nil {
+		return 0
+	}
+	return iterator.state.skipped
 }
 
 // Close closes the wrapped Cursor.  A cursor-less iterator has nothing to close.
@@ -66,10 +135,17 @@ func (iterator Iterator) Close() error {
 	return nil
 }
 
-// Error returns any error encountered while iterating the wrapped Cursor.
+// Error returns any error encountered while iterating the wrapped Cursor,
+// including a document that could not be decoded.
 func (iterator Iterator) Error() error {
+
+	if iterator.state != nil && iterator.state.err != nil {
+		return iterator.state.err
+	}
+
 	if iterator.cursor == nil {
 		return nil
 	}
+
 	return iterator.cursor.Err()
 }
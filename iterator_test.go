@@ -2,11 +2,16 @@ package mongodb
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
 	"github.com/benpate/exp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -120,3 +125,150 @@ func TestIterator_Error(t *testing.T) {
 	// A healthy cursor reports no error.
 	assert.NoError(t, iterator.Error())
 }
+
+/******************************************
+ * Decode Errors
+ ******************************************/
+
+// newTestCursor returns an Iterator over an in-memory cursor holding a valid
+// document, a document that cannot be decoded into a testPerson, and another
+// valid document.
+func newTestCursor(t *testing.T) (Iterator, primitive.ObjectID) {
+	t.Helper()
+
+	invalidID := primitive.NewObjectID()
+
+	cursor, err := mongo.NewCursorFromDocuments([]any{
+		bson.M{"_id": primitive.NewObjectID(), "name": "A", "age": 1},
+		bson.M{"_id": invalidID, "name": "B", "age": "not a number"},
+		bson.M{"_id": primitive.NewObjectID(), "name": "C", "age": 3},
+	}, nil, nil)
+	require.NoError(t, err)
+
+	return NewIterator(context.Background(), cursor), invalidID
+}
+
+// A document that cannot be decoded stops the iterator, and the error names
+// the offending document.
+func TestIterator_DecodeError(t *testing.T) {
+
+	iterator, invalidID := newTestCursor(t)
+
+	person := testPerson{}
+	assert.True(t, iterator.Next(&person))
+	assert.Equal(t, "A", person.Name)
+	assert.False(t, iterator.Next(&testPerson{}))
+	assert.False(t, iterator.Next(&testPerson{})) // stays stopped
+
+	err := iterator.Error()
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, derp.ErrorCode(err))
+	assert.Contains(t, derp.Message(err), "Decoding document")
+
+	derpError, ok := err.(derp.Error)
+	require.True(t, ok)
+	assert.Contains(t, derpError.Details[0], invalidID.Hex())
+	assert.Zero(t, iterator.Skipped())
+}
+
+// With SkipInvalid, bad documents are counted and iteration continues.
+func TestIterator_SkipInvalid(t *testing.T) {
+
+	iterator, _ := newTestCursor(t)
+	iterator.state.skipInvalid = true
+
+	names := make([]string, 0)
+	for person := (testPerson{}); iterator.Next(&person); person = (testPerson{}) {
+		names = append(names, person.Name)
+	}
+
+	assert.Equal(t, []string{"A", "C"}, names)
+	assert.Equal(t, 1, iterator.Skipped())
+	assert.NoError(t, iterator.Error())
+}
+
+// Seq2 yields decode errors alongside the other documents.
+func TestIterator_Seq2_DecodeError(t *testing.T) {
+
+	iterator, _ := newTestCursor(t)
+
+	names := make([]string, 0)
+	errorCount := 0
+	for person, err := range Seq2[testPerson](iterator) {
+		if err != nil {
+			errorCount++
+			continue
+		}
+		names = append(names, person.Name)
+	}
+
+	assert.Equal(t, []string{"A", "C"}, names)
+	assert.Equal(t, 1, errorCount)
+	assert.NoError(t, iterator.Error())
+}
+
+// The SkipInvalid option is passed from the Collection to its Iterator.
+func TestCollection_Iterator_SkipInvalid(t *testing.T) {
+
+	collection := getTestCollection(t)
+	require.NoError(t, collection.Save(newTestPerson("John Connor", 20), "seed"))
+	_, err := collection.Mongo().InsertOne(collection.Context(), bson.M{"name": "Broken", "age": "not a number"})
+	require.NoError(t, err)
+
+	result, err := collection.Iterator(exp.All(), SkipInvalid())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = result.Close() })
+
+	iterator := result.(Iterator)
+	count := 0
+	for person := (testPerson{}); iterator.Next(&person); person = (testPerson{}) {
+		count++
+	}
+
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, iterator.Skipped())
+	assert.NoError(t, iterator.Error())
+}
+
+/******************************************
+ * Total
+ ******************************************/
+
+// Total is only available when the CountTotal option is used.
+func TestIterator_Total_NotRequested(t *testing.T) {
+
+	iterator, _ := newTestCursor(t)
+	total, ok := iterator.Total()
+	assert.False(t, ok)
+	assert.Zero(t, total)
+
+	total, ok = Iterator{}.Total()
+	assert.False(t, ok)
+	assert.Zero(t, total)
+}
+
+// Total counts every matching record, while Count only reports the current batch.
+func TestCollection_Iterator_CountTotal(t *testing.T) {
+
+	collection := getTestCollection(t)
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		require.NoError(t, collection.Save(newTestPerson(name, 1), "seed"))
+	}
+	require.NoError(t, collection.Save(newTestPerson("Other", 2), "seed"))
+
+	result, err := collection.Iterator(exp.Equal("age", 1), option.MaxRows(2), CountTotal())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = result.Close() })
+
+	iterator := result.(Iterator)
+	total, ok := iterator.Total()
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, 2, iterator.Count())
+
+	// The total is also available through a TypedIterator.
+	typed := NewTypedIterator(result, func() *testPerson { return &testPerson{} })
+	total, ok = typed.Total()
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), total)
+}
@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// These are the document paths written by a journal.Journal that is embedded
+// into a data object under the "journal" key.  Operations that modify
+// documents in place (instead of replacing them with a freshly stamped object)
+// use them to keep the journal up to date.
+const (
+	journalField      = "journal"
+	journalCreateDate = "journal.createDate"
+	journalUpdateDate = "journal.updateDate"
+	journalDeleteDate = "journal.deleteDate"
+	journalNote       = "journal.note"
+	journalRevision   = "journal.signature"
+)
+
+// stampUpdated returns a copy of changes that also stamps the journal in the
+// same way as journal.SetUpdated: the UpdateDate is set, the Revision is
+// incremented, and the note is recorded when it is not empty.
+func stampUpdated(changes Changes, note string) Changes {
+
+	result := changes.clone().
+		Set(journalUpdateDate, time.Now().UnixMilli()).
+		Inc(journalRevision, 1)
+
+	if note != "" {
+		result = result.Set(journalNote, note)
+	}
+
+	return result
+}
+
+// stampInserted returns a copy of changes that also stamps a document that an
+// upsert inserts, in the same way as journal.SetCreated: the CreateDate (and
+// the UpdateDate, unless the changes already set it) are written only when a
+// new document is inserted.
+func stampInserted(changes Changes) Changes {
+
+	timestamp, updated := changes["$set"][journalUpdateDate]
+
+	if !updated {
+		timestamp = time.Now().UnixMilli()
+	}
+
+	result := changes.clone().add("$setOnInsert", journalCreateDate, timestamp)
+
+	if !updated {
+		result = result.add("$setOnInsert", journalUpdateDate, timestamp)
+	}
+
+	return result
+}
+
+// documentFields marshals an object into a map of top-level fields, with the
+// embedded journal flattened into dotted paths (such as "journal.updateDate")
+// so that individual journal fields can be written separately.
+func documentFields(object any) (bson.M, error) {
+
+	document := bson.D{}
+
+	raw, err := bson.Marshal(object)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	result := make(bson.M, len(document))
+
+	for _, element := range document {
+
+		if element.Key == journalField {
+			if journal, ok := element.Value.(bson.D); ok {
+				for _, entry := range journal {
+					result[journalField+"."+entry.Key] = entry.Value
+				}
+				continue
+			}
+		}
+
+		result[element.Key] = element.Value
+	}
+
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/******************************************
+ * stampInserted()
+ ******************************************/
+
+// Inserted documents share the UpdateDate that was already stamped.
+func TestStampInserted(t *testing.T) {
+
+	changes := stampUpdated(Changes{}.Set("name", "Sarah"), "")
+	result := stampInserted(changes)
+
+	updateDate := result["$set"][journalUpdateDate]
+	assert.Equal(t, bson.M{journalCreateDate: updateDate}, result["$setOnInsert"])
+
+	// The original changes are not modified
+	assert.NotContains(t, changes, "$setOnInsert")
+}
+
+// Unjournaled changes (such as counters) also set the UpdateDate on insert.
+func TestStampInserted_Unjournaled(t *testing.T) {
+
+	result := stampInserted(Changes{}.Inc("age", 1))
+
+	insert := result["$setOnInsert"]
+	require.NotNil(t, insert[journalCreateDate])
+	assert.Equal(t, insert[journalCreateDate], insert[journalUpdateDate])
+	assert.NotContains(t, result, "$set")
+}
+
+/******************************************
+ * documentFields()
+ ******************************************/
+
+// The embedded journal is flattened into dotted paths, while every other field
+// is kept as-is.
+func TestDocumentFields(t *testing.T) {
+
+	person := newTestPerson("John Connor", 20)
+	person.SetCreated("created")
+
+	fields, err := documentFields(person)
+	require.NoError(t, err)
+
+	assert.Equal(t, person.PersonID, fields["_id"])
+	assert.Equal(t, "John Connor", fields["name"])
+	assert.EqualValues(t, 20, fields["age"])
+	assert.Equal(t, person.CreateDate, fields[journalCreateDate])
+	assert.Equal(t, person.UpdateDate, fields[journalUpdateDate])
+	assert.Equal(t, int64(0), fields[journalDeleteDate])
+	assert.Equal(t, "created", fields[journalNote])
+	assert.Equal(t, int64(0), fields[journalRevision])
+	assert.NotContains(t, fields, journalField)
+}
+
+// Values that cannot be marshalled into a document return an error.
+func TestDocumentFields_Error(t *testing.T) {
+
+	_, err := documentFields("not a document")
+	require.Error(t, err)
+}
@@ -0,0 +1,386 @@
+package mongodb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxPageSize is the largest page size that PageAfter accepts
+const MaxPageSize = 10_000
+
+// PageAfter retrieves the page of objects that follows pageToken into the
+// target slice, and returns the token for the page after that.  Pass an empty
+// pageToken to retrieve the first page.  The returned token is empty when
+// there are no more pages.  pageSize must be between 1 and MaxPageSize.
+//
+// Pages are ordered by the Sort options, followed by `_id` to break ties.
+// Sort options after an explicit `_id` sort are ignored.
+// Instead of skipping rows, each page adds range criteria that start just
+// after the last row of the previous page, so every page is equally fast.
+// Sort fields may be null or missing, but should otherwise hold values of a
+// single type, and projection options must not remove them.
+//
+// Page tokens are signed with the key from the WithPageTokenKey setting, and
+// are only valid for the same sort order.  PageAfter fails with a 500 error if
+// no key is set, and with a 400 error if a token has been altered.
+func (c Collection) PageAfter(target any, criteria exp.Expression, pageToken string, pageSize int64, options ...option.Option) (string, error) {
+
+	const location = "data-mongo.Collection.PageAfter"
+
+	if len(c.settings.pageTokenKey) == 0 {
+		return "", derp.Internal(location, "Page token key is not configured.  Use the WithPageTokenKey setting")
+	}
+
+	if pageSize < 1 {
+		return "", derp.BadRequest(location, "Page size must be 1 or greater", pageSize)
+	}
+
+	if pageSize > MaxPageSize {
+		return "", derp.BadRequest(location, "Page size must not be greater than MaxPageSize", pageSize, MaxPageSize)
+	}
+
+	options = c.queryOptions(options...)
+	fields := keysetFields(options...)
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return "", derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	// Start after the last row of the previous page
+	if pageToken != "" {
+
+		values, err := decodePageToken(c.settings.pageTokenKey, pageToken, fields)
+
+		if err != nil {
+			return "", derp.Wrap(err, location, "Invalid page token")
+		}
+
+		criteriaBSON = bson.M{"$and": bson.A{criteriaBSON, keysetFilter(fields, values)}}
+	}
+
+	defer c.reportIfSlow(location, startTimer(), criteriaBSON)
+
+	// Read one extra row to learn whether there is another page
+	optionsBSON, err := findOptions(options...)
+
+	if err != nil {
+		return "", derp.Wrap(err, location, "Invalid options")
+	}
+
+	if optionsBSON == nil {
+		optionsBSON = mongoOptions.Find()
+	}
+
+	optionsBSON.SetSort(keysetSort(fields))
+	optionsBSON.SetLimit(pageSize + 1)
+	optionsBSON.Skip = nil
+
+	cursor, err := c.collection.Find(c.context, criteriaBSON, optionsBSON)
+
+	if err != nil {
+		return "", derp.Wrap(err, location, "Listing objects", criteriaBSON, options, derp.WithCode(queryErrorCode(err)))
+	}
+
+	documents := make([]bson.Raw, 0)
+
+	if err := cursor.All(c.context, &documents); err != nil {
+		return "", derp.Wrap(err, location, "Reading database objects", criteriaBSON, options, derp.WithCode(queryErrorCode(err)))
+	}
+
+	// Make a token from the last row of this page
+	nextToken := ""
+
+	if int64(len(documents)) > pageSize {
+
+		documents = documents[:pageSize]
+		nextToken, err = encodePageToken(c.settings.pageTokenKey, fields, keysetValues(fields, documents[pageSize-1]))
+
+		if err != nil {
+			return "", derp.Wrap(err, location, "Creating page token")
+		}
+	}
+
+	if err := unmarshalAll(documents, target); err != nil {
+		return "", derp.Wrap(err, location, "Unmarshaling database objects", criteriaBSON, options)
+	}
+
+	return nextToken, nil
+}
+
+/******************************************
+ * Sort Fields
+ ******************************************/
+
+// keysetField is one of the fields that a keyset page is sorted by
+type keysetField struct {
+	name      string
+	direction int
+}
+
+// keysetFields returns the fields that a keyset page is sorted by: every Sort
+// option in order, followed by `_id`, which makes the order unique.  An
+// explicit `_id` sort keeps its own position and direction, and any fields
+// after it are dropped, because `_id` is already unique.
+func keysetFields(options ...option.Option) []keysetField {
+
+	var sort bson.D
+	direction := 1
+
+	for _, opt := range options {
+		if sortOption, ok := opt.(option.SortOption); ok {
+			sort = appendSort(sort, sortOption)
+			direction = sortDirection(sortOption.Direction)
+		}
+	}
+
+	result := make([]keysetField, 0, len(sort)+1)
+
+	for _, element := range sort {
+
+		result = append(result, keysetField{name: element.Key, direction: element.Value.(int)})
+
+		if element.Key == "_id" {
+			return result
+		}
+	}
+
+	// `_id` breaks ties in the same direction as the last sort option
+	return append(result, keysetField{name: "_id", direction: direction})
+}
+
+// keysetSort returns the mongodb sort document for the keyset fields
+func keysetSort(fields []keysetField) bson.D {
+
+	result := make(bson.D, 0, len(fields))
+
+	for _, field := range fields {
+		result = append(result, bson.E{Key: field.name, Value: field.direction})
+	}
+
+	return result
+}
+
+// keysetFilter returns the range criteria that match every row after the
+// provided sort values.  For fields (a, b, _id) this is:
+// a > va  OR  (a = va AND b > vb)  OR  (a = va AND b = vb AND _id > vid)
+func keysetFilter(fields []keysetField, values []bson.RawValue) bson.M {
+
+	clauses := make(bson.A, 0, len(fields))
+
+	for index, field := range fields {
+
+		after, ok := keysetAfter(field, values[index])
+
+		if !ok {
+			continue
+		}
+
+		clause := bson.M{}
+
+		for previous := range index {
+			clause[fields[previous].name] = values[previous]
+		}
+
+		for key, value := range after {
+			clause[key] = value
+		}
+
+		clauses = append(clauses, clause)
+	}
+
+	return bson.M{"$or": clauses}
+}
+
+// keysetAfter returns the criteria that match every value of a field that
+// sorts after the provided value, or FALSE if no value can.  MongoDB only
+// compares values of the same type with $gt and $lt, but sorts null (and
+// missing) values before every other value, so null values are handled
+// separately: they come first in ascending order, and last in descending order.
+func keysetAfter(field keysetField, value bson.RawValue) (bson.M, bool) {
+
+	isNull := (value.Type == bsontype.Null) || (value.Type == bsontype.Undefined)
+
+	switch {
+
+	case field.direction > 0 && isNull:
+		return bson.M{field.name: bson.M{"$ne": nil}}, true
+
+	case field.direction > 0:
+		return bson.M{field.name: bson.M{"$gt": value}}, true
+
+	case isNull:
+		return nil, false
+
+	// Every document has an _id, so it is never null
+	case field.name == "_id":
+		return bson.M{field.name: bson.M{"$lt": value}}, true
+
+	default:
+		return bson.M{"$or": bson.A{
+			bson.M{field.name: bson.M{"$lt": value}},
+			bson.M{field.name: nil},
+		}}, true
+	}
+}
+
+// keysetValues returns the value of each keyset field in the document.  Missing
+// fields are treated as null.
+func keysetValues(fields []keysetField, document bson.Raw) []bson.RawValue {
+
+	result := make([]bson.RawValue, len(fields))
+
+	for index, field := range fields {
+
+		value, err := document.LookupErr(strings.Split(field.name, ".")...)
+
+		if err != nil {
+			value = bson.RawValue{Type: bsontype.Null}
+		}
+
+		result[index] = value
+	}
+
+	return result
+}
+
+// keysetSignature identifies the sort order of a page token, so that a token
+// cannot be used with a different sort order.
+func keysetSignature(fields []keysetField) []string {
+
+	result := make([]string, len(fields))
+
+	for index, field := range fields {
+		if field.direction < 0 {
+			result[index] = "-" + field.name
+		} else {
+			result[index] = field.name
+		}
+	}
+
+	return result
+}
+
+/******************************************
+ * Page Tokens
+ ******************************************/
+
+// pageToken is the payload of a page token
+type pageToken struct {
+	Sort   []string        `bson:"s"`
+	Values []bson.RawValue `bson:"v"`
+}
+
+// encodePageToken returns a signed, URL-safe page token for the provided sort
+// values.  The token is the BSON payload and its HMAC-SHA256 signature, each
+// base64 encoded and joined by a period.
+func encodePageToken(key []byte, fields []keysetField, values []bson.RawValue) (string, error) {
+
+	const location = "data-mongo.encodePageToken"
+
+	payload, err := bson.Marshal(pageToken{
+		Sort:   keysetSignature(fields),
+		Values: values,
+	})
+
+	if err != nil {
+		return "", derp.Wrap(err, location, "Encoding page token", derp.WithCode(http.StatusInternalServerError))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signPageToken(key, payload)), nil
+}
+
+// decodePageToken verifies a page token's signature and sort order, and returns
+// the sort values that it contains.
+func decodePageToken(key []byte, token string, fields []keysetField) ([]bson.RawValue, error) {
+
+	const location = "data-mongo.decodePageToken"
+
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+
+	if !ok {
+		return nil, derp.BadRequest(location, "Page token is malformed")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Page token is malformed", derp.WithCode(http.StatusBadRequest))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Page token is malformed", derp.WithCode(http.StatusBadRequest))
+	}
+
+	// Verify the signature before trusting anything in the payload
+	if !hmac.Equal(signature, signPageToken(key, payload)) {
+		return nil, derp.BadRequest(location, "Page token signature is invalid")
+	}
+
+	result := pageToken{}
+
+	if err := bson.Unmarshal(payload, &result); err != nil {
+		return nil, derp.Wrap(err, location, "Page token is malformed", derp.WithCode(http.StatusBadRequest))
+	}
+
+	if !slices.Equal(result.Sort, keysetSignature(fields)) || (len(result.Values) != len(fields)) {
+		return nil, derp.BadRequest(location, "Page token does not match the sort order", result.Sort)
+	}
+
+	return result.Values, nil
+}
+
+// signPageToken returns the HMAC-SHA256 signature of a page token payload
+func signPageToken(key []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+/******************************************
+ * Helpers
+ ******************************************/
+
+// unmarshalAll decodes each document into a new element of the target slice,
+// which must be a pointer to a slice.
+func unmarshalAll(documents []bson.Raw, target any) error {
+
+	const location = "data-mongo.unmarshalAll"
+
+	value := reflect.ValueOf(target)
+
+	if (value.Kind() != reflect.Pointer) || (value.Elem().Kind() != reflect.Slice) {
+		return derp.Internal(location, "Target must be a pointer to a slice", reflect.TypeOf(target))
+	}
+
+	slice := value.Elem()
+	result := reflect.MakeSlice(slice.Type(), 0, len(documents))
+
+	for _, document := range documents {
+
+		item := reflect.New(slice.Type().Elem())
+
+		if err := bson.Unmarshal(document, item.Interface()); err != nil {
+			return derp.Wrap(err, location, "Decoding document", document.Lookup("_id").String(), derp.WithCode(http.StatusInternalServerError))
+		}
+
+		result = reflect.Append(result, item.Elem())
+	}
+
+	slice.Set(result)
+	return nil
+}
@@ -0,0 +1,298 @@
+package mongodb
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testPageTokenKey = []byte("test-page-token-key")
+
+/******************************************
+ * Sort Fields
+ ******************************************/
+
+func TestKeysetFields(t *testing.T) {
+
+	// `_id` is always the final field
+	assert.Equal(t, []keysetField{{name: "_id", direction: 1}}, keysetFields())
+
+	// Sort options are kept in order, and `_id` follows the last direction
+	assert.Equal(t, []keysetField{
+		{name: "age", direction: 1},
+		{name: "name", direction: -1},
+		{name: "_id", direction: -1},
+	}, keysetFields(option.SortAsc("age"), option.MaxRows(10), option.SortDesc("name")))
+
+	// An explicit `_id` sort is not repeated
+	assert.Equal(t, []keysetField{{name: "_id", direction: -1}}, keysetFields(option.SortDesc("_id")))
+
+	// An `_id` sort keeps its direction, and later fields cannot change the order
+	assert.Equal(t, []keysetField{{name: "_id", direction: -1}}, keysetFields(option.SortDesc("_id"), option.SortAsc("name")))
+
+	assert.Equal(t, []keysetField{
+		{name: "age", direction: 1},
+		{name: "_id", direction: -1},
+	}, keysetFields(option.SortAsc("age"), option.SortDesc("_id"), option.SortAsc("name")))
+}
+
+func TestKeysetSort(t *testing.T) {
+
+	fields := keysetFields(option.SortAsc("age"), option.SortDesc("name"))
+
+	assert.Equal(t, bson.D{{Key: "age", Value: 1}, {Key: "name", Value: -1}, {Key: "_id", Value: -1}}, keysetSort(fields))
+}
+
+func TestKeysetFilter(t *testing.T) {
+
+	fields := keysetFields(option.SortAsc("age"), option.SortDesc("name"))
+	values := []bson.RawValue{rawValue(20), rawValue("Sarah"), rawValue("id")}
+
+	expected := bson.M{"$or": bson.A{
+		bson.M{"age": bson.M{"$gt": values[0]}},
+		bson.M{"age": values[0], "$or": bson.A{bson.M{"name": bson.M{"$lt": values[1]}}, bson.M{"name": nil}}},
+		bson.M{"age": values[0], "name": values[1], "_id": bson.M{"$lt": values[2]}},
+	}}
+
+	assert.Equal(t, expected, keysetFilter(fields, values))
+}
+
+// Null values sort first, so every non-null value follows a null in ascending
+// order, and nothing follows a null in descending order.
+func TestKeysetFilter_Null(t *testing.T) {
+
+	null := bson.RawValue{Type: bson.TypeNull}
+	id := rawValue("id")
+
+	fields := keysetFields(option.SortAsc("age"))
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"age": bson.M{"$ne": nil}},
+		bson.M{"age": null, "_id": bson.M{"$gt": id}},
+	}}, keysetFilter(fields, []bson.RawValue{null, id}))
+
+	fields = keysetFields(option.SortDesc("age"))
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"age": null, "_id": bson.M{"$lt": id}},
+	}}, keysetFilter(fields, []bson.RawValue{null, id}))
+}
+
+func TestKeysetValues(t *testing.T) {
+
+	fields := keysetFields(option.SortAsc("stats.views"), option.SortAsc("missing"))
+	document, err := bson.Marshal(bson.M{"_id": "abc", "stats": bson.M{"views": int32(7)}})
+	require.NoError(t, err)
+
+	values := keysetValues(fields, document)
+
+	require.Len(t, values, 3)
+	assert.Equal(t, int32(7), values[0].Int32())
+	assert.Equal(t, bson.TypeNull, values[1].Type)
+	assert.Equal(t, "abc", values[2].StringValue())
+}
+
+/******************************************
+ * Page Tokens
+ ******************************************/
+
+func TestPageToken_RoundTrip(t *testing.T) {
+
+	id := primitive.NewObjectID()
+	fields := keysetFields(option.SortAsc("name"))
+	values := []bson.RawValue{rawValue("Sarah"), rawValue(id)}
+
+	token, err := encodePageToken(testPageTokenKey, fields, values)
+	require.NoError(t, err)
+
+	result, err := decodePageToken(testPageTokenKey, token, fields)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "Sarah", result[0].StringValue())
+	assert.Equal(t, id, result[1].ObjectID()) // types are preserved
+}
+
+func TestPageToken_Rejected(t *testing.T) {
+
+	fields := keysetFields(option.SortAsc("name"))
+	token, err := encodePageToken(testPageTokenKey, fields, []bson.RawValue{rawValue("Sarah"), rawValue("id")})
+	require.NoError(t, err)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	otherToken, err := encodePageToken(testPageTokenKey, fields, []bson.RawValue{rawValue("John"), rawValue("id")})
+	require.NoError(t, err)
+	otherPayload, _, _ := strings.Cut(otherToken, ".")
+
+	tests := map[string]struct {
+		key    []byte
+		token  string
+		fields []keysetField
+	}{
+		"wrong key":         {key: []byte("other-key"), token: token, fields: fields},
+		"altered payload":   {key: testPageTokenKey, token: otherPayload + "." + signature, fields: fields},
+		"missing signature": {key: testPageTokenKey, token: payload, fields: fields},
+		"not base64":        {key: testPageTokenKey, token: "!!!." + signature, fields: fields},
+		"different sort":    {key: testPageTokenKey, token: token, fields: keysetFields(option.SortDesc("name"))},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodePageToken(test.key, test.token, test.fields)
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+		})
+	}
+}
+
+/******************************************
+ * Collection.PageAfter()
+ ******************************************/
+
+// PageAfter refuses to run without a signing key.
+func TestCollection_PageAfter_NoKey(t *testing.T) {
+
+	result := make([]testPerson, 0)
+	_, err := Collection{}.PageAfter(&result, exp.All(), "", 10)
+
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, derp.ErrorCode(err))
+}
+
+func TestCollection_PageAfter_InvalidPageSize(t *testing.T) {
+
+	collection := Collection{}.With(WithPageTokenKey(testPageTokenKey))
+	result := make([]testPerson, 0)
+	_, err := collection.PageAfter(&result, exp.All(), "", 0)
+
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+
+	// Sizes past the maximum are refused instead of overflowing the limit
+	for _, pageSize := range []int64{MaxPageSize + 1, 1e12, math.MaxInt64} {
+		_, err = collection.PageAfter(&result, exp.All(), "", pageSize)
+		assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err), "pageSize=%d", pageSize)
+	}
+}
+
+// Walking every page returns every record exactly once, in order, even when
+// sort values are duplicated.
+func TestCollection_PageAfter(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithPageTokenKey(testPageTokenKey))
+
+	for index, name := range []string{"A", "B", "C", "D", "E"} {
+		require.NoError(t, collection.Save(newTestPerson(name, 10+(index/2)), "seed"))
+	}
+
+	names := make([]string, 0)
+	pageToken := ""
+	pageCount := 0
+
+	for {
+		page := make([]testPerson, 0)
+		nextToken, err := collection.PageAfter(&page, exp.All(), pageToken, 2, option.SortDesc("age"))
+		require.NoError(t, err)
+
+		pageCount++
+		for _, person := range page {
+			names = append(names, person.Name)
+		}
+
+		if nextToken == "" {
+			break
+		}
+		pageToken = nextToken
+	}
+
+	assert.Equal(t, 3, pageCount)
+	require.Len(t, names, 5)
+	assert.Equal(t, "E", names[0]) // age 12
+	assert.ElementsMatch(t, []string{"A", "B", "C", "D", "E"}, names)
+}
+
+// Documents with a null or missing sort field are returned too, first in
+// ascending order and last in descending order.
+func TestCollection_PageAfter_MissingSortField(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithPageTokenKey(testPageTokenKey))
+
+	_, err := collection.Mongo().InsertMany(context.Background(), []any{
+		bson.M{"_id": "1", "name": "A", "rank": 2},
+		bson.M{"_id": "2", "name": "B"},
+		bson.M{"_id": "3", "name": "C", "rank": 1},
+		bson.M{"_id": "4", "name": "D", "rank": nil},
+		bson.M{"_id": "5", "name": "E"},
+	})
+	require.NoError(t, err)
+
+	// walk returns the names on every page, in order
+	walk := func(sort option.Option) []string {
+		names := make([]string, 0)
+		pageToken := ""
+
+		for {
+			page := make([]bson.M, 0)
+			nextToken, err := collection.PageAfter(&page, exp.All(), pageToken, 2, sort)
+			require.NoError(t, err)
+
+			for _, document := range page {
+				names = append(names, document["name"].(string))
+			}
+
+			if nextToken == "" {
+				return names
+			}
+
+			pageToken = nextToken
+		}
+	}
+
+	assert.Equal(t, []string{"B", "D", "E", "C", "A"}, walk(option.SortAsc("rank")))
+	assert.Equal(t, []string{"A", "C", "E", "D", "B"}, walk(option.SortDesc("rank")))
+}
+
+// A token from one sort order cannot be used with another.
+func TestCollection_PageAfter_SortMismatch(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithPageTokenKey(testPageTokenKey))
+	for _, name := range []string{"A", "B", "C"} {
+		require.NoError(t, collection.Save(newTestPerson(name, 1), "seed"))
+	}
+
+	page := make([]testPerson, 0)
+	nextToken, err := collection.PageAfter(&page, exp.All(), "", 2, option.SortAsc("name"))
+	require.NoError(t, err)
+	require.NotEmpty(t, nextToken)
+
+	_, err = collection.PageAfter(&page, exp.All(), nextToken, 2, option.SortAsc("age"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+}
+
+func TestTypedCollection_PageAfter(t *testing.T) {
+
+	collection := getTestCollection(t).With(WithPageTokenKey(testPageTokenKey))
+	people := newTestPersonCollection(collection)
+	for _, name := range []string{"A", "B", "C"} {
+		require.NoError(t, people.Save(newTestPerson(name, 1), "seed"))
+	}
+
+	first, nextToken, err := people.PageAfter(exp.All(), "", 2, option.SortAsc("name"))
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, "A", first[0].Name)
+
+	second, nextToken, err := people.PageAfter(exp.All(), nextToken, 2, option.SortAsc("name"))
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, "C", second[0].Name)
+	assert.Empty(t, nextToken)
+}
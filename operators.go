@@ -0,0 +1,143 @@
+package mongodb
+
+import (
+	"slices"
+
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Operators supported by this package in addition to the ones defined by exp.
+const (
+	// OperatorSize matches arrays with exactly the given number of elements
+	OperatorSize = "SIZE"
+
+	// OperatorType matches values of the given BSON type (or any of several types)
+	OperatorType = "TYPE"
+
+	// OperatorMod matches numbers with the given remainder when divided by the divisor
+	OperatorMod = "MOD"
+
+	// OperatorRegex matches strings against a regular expression.  Patterns are
+	// checked according to the current RegexMode.
+	OperatorRegex = "REGEX"
+)
+
+// ModValue is the value of an OperatorMod predicate
+type ModValue struct {
+	Divisor   int64
+	Remainder int64
+}
+
+// Size creates a new Predicate that matches arrays with exactly `length` elements
+func Size(field string, length int) exp.Predicate {
+	return exp.New(field, OperatorSize, length)
+}
+
+// Type creates a new Predicate that matches values of a BSON type.  The type
+// may be a type alias (such as "string" or "number"), a bsontype.Type, or a
+// slice of aliases to match any of them.
+func Type(field string, bsonType any) exp.Predicate {
+	return exp.New(field, OperatorType, bsonType)
+}
+
+// Mod creates a new Predicate that matches numbers with the given remainder
+// when divided by the divisor
+func Mod(field string, divisor int64, remainder int64) exp.Predicate {
+	return exp.New(field, OperatorMod, ModValue{Divisor: divisor, Remainder: remainder})
+}
+
+// Regex creates a new Predicate that matches strings against a regular
+// expression.  Unlike exp.Contains, the pattern is NOT escaped, so it must
+// never be built from untrusted input.  The pattern may also be a
+// primitive.Regex, to include options such as "i".
+func Regex(field string, pattern any) exp.Predicate {
+	return exp.New(field, OperatorRegex, pattern)
+}
+
+// typeAliases are the BSON type aliases that MongoDB's $type operator accepts
+var typeAliases = []string{
+	"double", "string", "object", "array", "binData", "undefined", "objectId",
+	"bool", "date", "null", "regex", "dbPointer", "javascript", "symbol",
+	"int", "timestamp", "long", "decimal", "minKey", "maxKey", "number",
+}
+
+// sizeBSON returns the $size operator for a Size predicate's value, which must
+// be a non-negative integer
+func sizeBSON(value any) (bson.M, error) {
+
+	const location = "data-mongo.sizeBSON"
+
+	var length int64
+
+	switch typed := value.(type) {
+	case int:
+		length = int64(typed)
+	case int32:
+		length = int64(typed)
+	case int64:
+		length = typed
+	default:
+		return nil, derp.BadRequest(location, "Size value must be an integer", value)
+	}
+
+	if length < 0 {
+		return nil, derp.BadRequest(location, "Size value must not be negative", length)
+	}
+
+	return bson.M{"$size": length}, nil
+}
+
+// typeBSON returns the $type operator for a Type predicate's value, which must
+// be a known type alias, a bsontype.Type, or a non-empty slice of aliases
+func typeBSON(value any) (bson.M, error) {
+
+	const location = "data-mongo.typeBSON"
+
+	switch typed := value.(type) {
+
+	case string:
+		if slices.Contains(typeAliases, typed) {
+			return bson.M{"$type": typed}, nil
+		}
+
+	case bsontype.Type:
+		if typed.IsValid() {
+			return bson.M{"$type": int32(typed)}, nil
+		}
+
+	case []string:
+		valid := len(typed) > 0
+
+		for _, alias := range typed {
+			valid = valid && slices.Contains(typeAliases, alias)
+		}
+
+		if valid {
+			return bson.M{"$type": typed}, nil
+		}
+	}
+
+	return nil, derp.BadRequest(location, "Type value must be a BSON type alias, a bsontype.Type, or a slice of aliases", value)
+}
+
+// modBSON returns the $mod operator for a Mod predicate's value, which must be
+// a ModValue with a non-zero divisor
+func modBSON(value any) (bson.M, error) {
+
+	const location = "data-mongo.modBSON"
+
+	mod, ok := value.(ModValue)
+
+	if !ok {
+		return nil, derp.BadRequest(location, "Mod value must be a ModValue", value)
+	}
+
+	if mod.Divisor == 0 {
+		return nil, derp.BadRequest(location, "Mod divisor must not be zero")
+	}
+
+	return bson.M{"$mod": bson.A{mod.Divisor, mod.Remainder}}, nil
+}
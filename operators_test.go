@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOperators_Size(t *testing.T) {
+
+	assert.Equal(t, bson.M{"tags": bson.M{"$size": int64(3)}}, ExpressionToBSON(Size("tags", 3)))
+	assert.Equal(t, bson.M{"$size": int64(0)}, mustOperatorBSON(t, OperatorSize, int64(0)))
+
+	for _, value := range []any{-1, "3", 3.5, nil} {
+		_, err := operatorBSON(OperatorSize, value)
+		assert.True(t, derp.IsBadRequest(err), "value=%v", value)
+	}
+}
+
+func TestOperators_Type(t *testing.T) {
+
+	assert.Equal(t, bson.M{"age": bson.M{"$type": "number"}}, ExpressionToBSON(Type("age", "number")))
+	assert.Equal(t, bson.M{"$type": int32(2)}, mustOperatorBSON(t, OperatorType, bsontype.String))
+	assert.Equal(t, bson.M{"$type": []string{"int", "long"}}, mustOperatorBSON(t, OperatorType, []string{"int", "long"}))
+
+	for _, value := range []any{"integer", bsontype.Type(0), []string{}, []string{"int", "integer"}, 2} {
+		_, err := operatorBSON(OperatorType, value)
+		assert.True(t, derp.IsBadRequest(err), "value=%v", value)
+	}
+}
+
+func TestOperators_Mod(t *testing.T) {
+
+	assert.Equal(t, bson.M{"age": bson.M{"$mod": bson.A{int64(4), int64(1)}}}, ExpressionToBSON(Mod("age", 4, 1)))
+
+	for _, value := range []any{ModValue{Divisor: 0, Remainder: 1}, []int{4, 1}, 4} {
+		_, err := operatorBSON(OperatorMod, value)
+		assert.True(t, derp.IsBadRequest(err), "value=%v", value)
+	}
+}
+
+func TestOperators_Regex(t *testing.T) {
+
+	assert.Equal(t,
+		bson.M{"name": bson.M{"$regex": primitive.Regex{Pattern: "^J(oh)?n$"}}},
+		ExpressionToBSON(Regex("name", "^J(oh)?n$")))
+
+	assert.Equal(t,
+		bson.M{"$regex": primitive.Regex{Pattern: "^john", Options: "i"}},
+		mustOperatorBSON(t, OperatorRegex, primitive.Regex{Pattern: "^john", Options: "i"}))
+
+	// Unlike Contains, the pattern is not escaped
+	assert.Equal(t,
+		bson.M{"$regex": primitive.Regex{Pattern: "a.b"}},
+		mustOperatorBSON(t, OperatorRegex, "a.b"))
+
+	for _, value := range []any{42, primitive.Regex{Pattern: "a", Options: "g"}} {
+		_, err := operatorBSON(OperatorRegex, value)
+		assert.True(t, derp.IsBadRequest(err), "value=%v", value)
+	}
+}
+
+// The new operators can be negated like any other.
+func TestOperators_Not(t *testing.T) {
+	assert.Equal(t, bson.M{"tags": bson.M{"$not": bson.M{"$size": int64(0)}}}, ExpressionToBSON(Not(Size("tags", 0))))
+	assert.Equal(t, bson.M{"age": bson.M{"$not": bson.M{"$type": "string"}}}, ExpressionToBSON(Not(Type("age", "string"))))
+}
+
+// The new operators apply to scalar array elements inside of ElemMatch.
+func TestOperators_ElemMatch(t *testing.T) {
+	assert.Equal(t,
+		bson.M{"scores": bson.M{"$elemMatch": bson.M{"$mod": bson.A{int64(2), int64(0)}, "$gt": 10}}},
+		ExpressionToBSON(ElemMatch("scores", Mod("", 2, 0).AndGreaterThan("", 10))))
+}
+
+// Predicates built by hand with the operator constants work the same way.
+func TestOperators_New(t *testing.T) {
+	assert.Equal(t, ExpressionToBSON(Size("tags", 3)), ExpressionToBSON(exp.New("tags", OperatorSize, 3)))
+}
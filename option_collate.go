@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeCollate is the token that designates the "collate" query option
+const TypeCollate = "COLLATE"
+
+// CollateOption is a query option that sets the collation for a query
+type CollateOption Collation
+
+// Collate returns a query option that compares strings using the provided
+// collation.  It overrides the WithCollation setting.  A CaseSensitive option
+// in the same query overrides the collation's strength.
+func Collate(collation Collation) dataOption.Option {
+	return CollateOption(collation)
+}
+
+// OptionType identifies this object as a query option
+func (option CollateOption) OptionType() string {
+	return TypeCollate
+}
+
+// Collation returns the collation to use for the query
+func (option CollateOption) Collation() Collation {
+	return Collation(option)
+}
@@ -0,0 +1,20 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollate(t *testing.T) {
+
+	collation := Collation{Locale: "ja", NumericOrdering: true}
+	option := Collate(collation)
+
+	collate, ok := option.(CollateOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeCollate, option.OptionType())
+	assert.Equal(t, collation, collate.Collation())
+}
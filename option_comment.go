@@ -0,0 +1,59 @@
+package mongodb
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeComment is the token that designates the "comment" query option
+const TypeComment = "COMMENT"
+
+// CommentOption is a query option that attaches a comment to a query, which
+// appears in the database profiler, logs, and currentOp output.
+type CommentOption string
+
+// Comment returns a query option that attaches a comment to a query
+func Comment(comment string) dataOption.Option {
+	return CommentOption(comment)
+}
+
+// CallerComment returns a query option that attaches the location of the
+// calling code (such as "user.go:42 service.User.List") to a query, so that
+// profiler entries can be traced back to the code that made them.
+func CallerComment() dataOption.Option {
+	return CommentOption(callerLocation(2))
+}
+
+// OptionType identifies this object as a query option
+func (option CommentOption) OptionType() string {
+	return TypeComment
+}
+
+// Comment returns the text of the comment
+func (option CommentOption) Comment() string {
+	return string(option)
+}
+
+// callerLocation describes the code that is `skip` frames up the call stack,
+// as "file.go:line package.Function".
+func callerLocation(skip int) string {
+
+	pc, file, line, ok := runtime.Caller(skip)
+
+	if !ok {
+		return "unknown"
+	}
+
+	result := filepath.Base(file) + ":" + strconv.Itoa(line)
+
+	if function := runtime.FuncForPC(pc); function != nil {
+		name := function.Name()
+		result += " " + name[strings.LastIndex(name, "/")+1:]
+	}
+
+	return result
+}
@@ -0,0 +1,41 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComment(t *testing.T) {
+
+	option := Comment("listing users")
+
+	comment, ok := option.(CommentOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeComment, option.OptionType())
+	assert.Equal(t, "listing users", comment.Comment())
+}
+
+// CallerComment names the code that created the option.
+func TestCallerComment(t *testing.T) {
+
+	comment := CallerComment().(CommentOption).Comment()
+
+	assert.Regexp(t, `^option_comment_test\.go:\d+ data-mongo\.TestCallerComment$`, comment)
+}
+
+// Every read and update carries the comment.
+func TestComment_Options(t *testing.T) {
+
+	option := Comment("listing users")
+
+	assert.Equal(t, pointerTo("listing users"), mustFindOptions(t, option).Comment)
+	assert.Equal(t, pointerTo("listing users"), mustFindOneOptions(t, option).Comment)
+	assert.Equal(t, pointerTo("listing users"), countOptions(option).Comment)
+	assert.Equal(t, pointerTo("listing users"), totalOptions(option).Comment)
+	assert.Equal(t, "listing users", mustFindOneAndUpdateOptions(t, option).Comment)
+	assert.Equal(t, "listing users", mustFindOneAndDeleteOptions(t, option).Comment)
+	assert.Equal(t, "listing users", updateOptions(option).Comment)
+}
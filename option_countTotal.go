@@ -0,0 +1,34 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeCountTotal is the token that designates the "count total" query option
+const TypeCountTotal = "COUNTTOTAL"
+
+// CountTotalOption is a query option that tells Collection.Iterator to count
+// every record that matches the query, regardless of MaxRows.
+type CountTotalOption struct{}
+
+// CountTotal returns a query option that tells Collection.Iterator to count
+// every record that matches the query.  The count is made with a separate
+// CountDocuments call, and is available from Iterator.Total.
+func CountTotal() dataOption.Option {
+	return CountTotalOption{}
+}
+
+// OptionType identifies this object as a query option
+func (option CountTotalOption) OptionType() string {
+	return TypeCountTotal
+}
+
+// hasCountTotal returns TRUE if the options include a CountTotalOption.
+func hasCountTotal(options ...dataOption.Option) bool {
+	for _, option := range options {
+		if _, ok := option.(CountTotalOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/data/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountTotal(t *testing.T) {
+
+	option := CountTotal()
+
+	_, ok := option.(CountTotalOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeCountTotal, option.OptionType())
+}
+
+func TestHasCountTotal(t *testing.T) {
+	assert.False(t, hasCountTotal())
+	assert.False(t, hasCountTotal(option.MaxRows(10), IncludeDeleted()))
+	assert.True(t, hasCountTotal(option.MaxRows(10), CountTotal()))
+}
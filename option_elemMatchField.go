@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TypeElemMatchField is the token that designates the "elemMatch field" query option
+const TypeElemMatchField = "ELEMMATCHFIELD"
+
+// ElemMatchFieldOption is a query option that returns only the first element
+// of an array field that matches some criteria, using an $elemMatch projection.
+type ElemMatchFieldOption struct {
+	Field    string         // The name of the array field
+	Criteria exp.Expression // The criteria, relative to each array element
+}
+
+// ElemMatchField returns a query option that returns only the first element of
+// an array field that matches the criteria.  Field names in the criteria are
+// relative to each array element.
+func ElemMatchField(field string, criteria exp.Expression) dataOption.Option {
+	return ElemMatchFieldOption{
+		Field:    field,
+		Criteria: criteria,
+	}
+}
+
+// OptionType identifies this object as a query option
+func (option ElemMatchFieldOption) OptionType() string {
+	return TypeElemMatchField
+}
+
+// Projection returns the mongodb projection for this option, or a 400 error if
+// the criteria cannot be translated exactly.  Criteria fields are relative to
+// each element, as with the ElemMatch expression.
+func (option ElemMatchFieldOption) Projection() (bson.M, error) {
+
+	const location = "data-mongo.ElemMatchFieldOption.Projection"
+
+	criteria, err := elemMatchBSON(option.Criteria)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Translating element criteria", option.Field)
+	}
+
+	return bson.M{"$elemMatch": criteria}, nil
+}
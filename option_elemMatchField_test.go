@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestElemMatchField(t *testing.T) {
+
+	option := ElemMatchField("recipients", exp.Equal("role", "admin"))
+
+	elemMatch, ok := option.(ElemMatchFieldOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeElemMatchField, option.OptionType())
+	assert.Equal(t, "recipients", elemMatch.Field)
+
+	projection, err := elemMatch.Projection()
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$elemMatch": bson.M{"role": bson.M{"$eq": "admin"}}}, projection)
+}
+
+// Criteria that cannot be translated are a 400 error, instead of a projection
+// that silently returns no elements.
+func TestElemMatchField_Invalid(t *testing.T) {
+
+	option := ElemMatchField("recipients", exp.New("role", "UNKNOWN", "admin"))
+
+	_, err := option.(ElemMatchFieldOption).Projection()
+	require.Error(t, err)
+	assert.True(t, derp.IsBadRequest(err))
+
+	_, err = findOptions(option)
+	assert.True(t, derp.IsBadRequest(err))
+
+	_, err = findOneOptions(option)
+	assert.True(t, derp.IsBadRequest(err))
+
+	_, err = findOneAndUpdateOptions(option)
+	assert.True(t, derp.IsBadRequest(err))
+
+	_, err = findOneAndDeleteOptions(option)
+	assert.True(t, derp.IsBadRequest(err))
+}
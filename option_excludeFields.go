@@ -0,0 +1,30 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeExcludeFields is the token that designates the "exclude fields" query option
+const TypeExcludeFields = "EXCLUDEFIELDS"
+
+// ExcludeFieldsOption is a query option that removes fields from the results,
+// while keeping every other field.
+type ExcludeFieldsOption []string
+
+// ExcludeFields returns a query option that removes the named fields (such as
+// large text bodies or histories) from the results.  It cannot be combined with
+// the Fields option, except to exclude `_id`; queries that do so fail with a
+// 400 error.
+func ExcludeFields(fields ...string) dataOption.Option {
+	return ExcludeFieldsOption(fields)
+}
+
+// OptionType identifies this object as a query option
+func (option ExcludeFieldsOption) OptionType() string {
+	return TypeExcludeFields
+}
+
+// Fields returns the names of the fields to exclude
+func (option ExcludeFieldsOption) Fields() []string {
+	return []string(option)
+}
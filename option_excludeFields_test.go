@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestExcludeFields(t *testing.T) {
+
+	option := ExcludeFields("body", "history")
+
+	exclude, ok := option.(ExcludeFieldsOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeExcludeFields, option.OptionType())
+	assert.Equal(t, []string{"body", "history"}, exclude.Fields())
+}
+
+func TestExcludeFields_Projection(t *testing.T) {
+
+	result := mustFindOptions(t, ExcludeFields("body", "", "history"))
+
+	require.NotNil(t, result)
+	assert.Equal(t, bson.D{{Key: "body", Value: 0}, {Key: "history", Value: 0}}, result.Projection)
+}
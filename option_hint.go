@@ -0,0 +1,32 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TypeHint is the token that designates the "index hint" query option
+const TypeHint = "HINT"
+
+// HintOption is a query option that tells the query planner which index to use
+type HintOption struct {
+	Index any // The index name (string) or key pattern (bson.D)
+}
+
+// Hint returns a query option that tells the query planner to use the named
+// index.  The query fails if the index does not exist.
+func Hint(indexName string) dataOption.Option {
+	return HintOption{Index: indexName}
+}
+
+// HintKeys returns a query option that tells the query planner to use the
+// index with the provided key pattern, such as {name: 1, age: -1}.  The query
+// fails if the index does not exist.
+func HintKeys(keys bson.D) dataOption.Option {
+	return HintOption{Index: keys}
+}
+
+// OptionType identifies this object as a query option
+func (option HintOption) OptionType() string {
+	return TypeHint
+}
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestHint(t *testing.T) {
+
+	option := Hint("name_1")
+
+	hint, ok := option.(HintOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeHint, option.OptionType())
+	assert.Equal(t, "name_1", hint.Index)
+}
+
+func TestHintKeys(t *testing.T) {
+
+	keys := bson.D{{Key: "name", Value: 1}, {Key: "age", Value: -1}}
+	option := HintKeys(keys)
+
+	hint, ok := option.(HintOption)
+	require.True(t, ok)
+	assert.Equal(t, keys, hint.Index)
+}
+
+// Every read and update honors the hint.
+func TestHint_Options(t *testing.T) {
+
+	option := Hint("name_1")
+
+	assert.Equal(t, "name_1", mustFindOptions(t, option).Hint)
+	assert.Equal(t, "name_1", mustFindOneOptions(t, option).Hint)
+	assert.Equal(t, "name_1", countOptions(option).Hint)
+	assert.Equal(t, "name_1", totalOptions(option).Hint)
+	assert.Equal(t, "name_1", mustFindOneAndUpdateOptions(t, option).Hint)
+	assert.Equal(t, "name_1", mustFindOneAndDeleteOptions(t, option).Hint)
+	assert.Equal(t, "name_1", updateOptions(option).Hint)
+}
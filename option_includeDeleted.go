@@ -0,0 +1,34 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeIncludeDeleted is the token that designates the "include deleted" query option
+const TypeIncludeDeleted = "INCLUDEDELETED"
+
+// IncludeDeletedOption is a query option that includes virtually-deleted
+// documents in the results, even when the Collection excludes them by default.
+type IncludeDeletedOption struct{}
+
+// IncludeDeleted returns a query option that includes virtually-deleted
+// documents in the results.  It is meant for admin and audit screens on
+// Collections that use WithoutDeleted.
+func IncludeDeleted() dataOption.Option {
+	return IncludeDeletedOption{}
+}
+
+// OptionType identifies this object as a query option
+func (option IncludeDeletedOption) OptionType() string {
+	return TypeIncludeDeleted
+}
+
+// hasIncludeDeleted returns TRUE if the options include an IncludeDeletedOption.
+func hasIncludeDeleted(options ...dataOption.Option) bool {
+	for _, option := range options {
+		if _, ok := option.(IncludeDeletedOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/data/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncludeDeleted(t *testing.T) {
+
+	option := IncludeDeleted()
+
+	_, ok := option.(IncludeDeletedOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeIncludeDeleted, option.OptionType())
+}
+
+func TestHasIncludeDeleted(t *testing.T) {
+	assert.False(t, hasIncludeDeleted())
+	assert.False(t, hasIncludeDeleted(option.MaxRows(10), option.SortAsc("name")))
+	assert.True(t, hasIncludeDeleted(option.MaxRows(10), IncludeDeleted()))
+}
@@ -0,0 +1,32 @@
+package mongodb
+
+import (
+	"time"
+
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeMaxTime is the token that designates the "max time" query option
+const TypeMaxTime = "MAXTIME"
+
+// MaxTimeOption is a query option that limits how long the server may spend
+// running a query.
+type MaxTimeOption time.Duration
+
+// MaxTime returns a query option that limits how long the server may spend
+// running a query (maxTimeMS).  Queries that exceed it fail with a 504
+// Gateway Timeout error.  It overrides the WithMaxTime setting, and a zero
+// duration removes the limit.
+func MaxTime(duration time.Duration) dataOption.Option {
+	return MaxTimeOption(duration)
+}
+
+// OptionType identifies this object as a query option
+func (option MaxTimeOption) OptionType() string {
+	return TypeMaxTime
+}
+
+// MaxTime returns the maximum time that the query may run
+func (option MaxTimeOption) MaxTime() time.Duration {
+	return time.Duration(option)
+}
@@ -0,0 +1,20 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxTime(t *testing.T) {
+
+	option := MaxTime(2 * time.Second)
+
+	maxTime, ok := option.(MaxTimeOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeMaxTime, option.OptionType())
+	assert.Equal(t, 2*time.Second, maxTime.MaxTime())
+}
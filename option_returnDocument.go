@@ -0,0 +1,46 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeReturnDocument is the token that designates which version of a document
+// is returned by an atomic read-modify-write
+const TypeReturnDocument = "RETURNDOCUMENT"
+
+// ReturnDocumentOption is a query option that designates whether an atomic
+// read-modify-write returns the document as it was before the change, or as
+// it is after the change.
+type ReturnDocumentOption bool
+
+// ReturnBefore returns a query option that returns documents as they were
+// before they were modified.  This is the default behavior.
+func ReturnBefore() dataOption.Option {
+	return ReturnDocumentOption(false)
+}
+
+// ReturnAfter returns a query option that returns documents as they are after
+// they were modified.
+func ReturnAfter() dataOption.Option {
+	return ReturnDocumentOption(true)
+}
+
+// OptionType identifies this object as a query option
+func (option ReturnDocumentOption) OptionType() string {
+	return TypeReturnDocument
+}
+
+// After returns TRUE if the modified version of the document should be returned
+func (option ReturnDocumentOption) After() bool {
+	return bool(option)
+}
+
+// hasReturnDocument returns TRUE if the options include a ReturnDocumentOption.
+func hasReturnDocument(options ...dataOption.Option) bool {
+	for _, option := range options {
+		if _, ok := option.(ReturnDocumentOption); ok {
+			return true
+		}
+	}
+	return false
+}
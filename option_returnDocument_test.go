@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReturnDocument(t *testing.T) {
+
+	before, ok := ReturnBefore().(ReturnDocumentOption)
+	require.True(t, ok)
+	assert.False(t, before.After())
+	assert.Equal(t, TypeReturnDocument, before.OptionType())
+
+	after, ok := ReturnAfter().(ReturnDocumentOption)
+	require.True(t, ok)
+	assert.True(t, after.After())
+	assert.Equal(t, TypeReturnDocument, after.OptionType())
+}
+
+func TestHasReturnDocument(t *testing.T) {
+	assert.False(t, hasReturnDocument())
+	assert.False(t, hasReturnDocument(Upsert()))
+	assert.True(t, hasReturnDocument(Upsert(), ReturnBefore()))
+	assert.True(t, hasReturnDocument(ReturnAfter()))
+}
@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeSkip is the token that designates the number of records to skip
+const TypeSkip = "SKIP"
+
+// SkipOption is a query option that skips a number of rows at the beginning of
+// a dataset.  Combined with MaxRows and Sort, it returns a single page of
+// results.
+type SkipOption int64
+
+// Skip returns a query option that will skip the first rows of the query results
+func Skip(rows int64) dataOption.Option {
+	return SkipOption(rows)
+}
+
+// OptionType identifies this object as a query option
+func (option SkipOption) OptionType() string {
+	return TypeSkip
+}
+
+// Skip returns the number of rows to skip
+func (option SkipOption) Skip() int64 {
+	return int64(option)
+}
@@ -0,0 +1,34 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeSkipInvalid is the token that designates the "skip invalid" query option
+const TypeSkipInvalid = "SKIPINVALID"
+
+// SkipInvalidOption is a query option that tells an Iterator to skip documents
+// that cannot be decoded, instead of stopping at the first one.
+type SkipInvalidOption struct{}
+
+// SkipInvalid returns a query option that tells an Iterator to skip documents
+// that cannot be decoded.  Each skipped document is reported via derp.Report
+// and counted by Iterator.Skipped.
+func SkipInvalid() dataOption.Option {
+	return SkipInvalidOption{}
+}
+
+// OptionType identifies this object as a query option
+func (option SkipInvalidOption) OptionType() string {
+	return TypeSkipInvalid
+}
+
+// hasSkipInvalid returns TRUE if the options include a SkipInvalidOption.
+func hasSkipInvalid(options ...dataOption.Option) bool {
+	for _, option := range options {
+		if _, ok := option.(SkipInvalidOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/benpate/data/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipInvalid(t *testing.T) {
+
+	option := SkipInvalid()
+
+	_, ok := option.(SkipInvalidOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeSkipInvalid, option.OptionType())
+}
+
+func TestHasSkipInvalid(t *testing.T) {
+	assert.False(t, hasSkipInvalid())
+	assert.False(t, hasSkipInvalid(option.MaxRows(10), IncludeDeleted()))
+	assert.True(t, hasSkipInvalid(option.MaxRows(10), SkipInvalid()))
+}
@@ -0,0 +1,19 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkip(t *testing.T) {
+
+	option := Skip(20)
+
+	skip, ok := option.(SkipOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeSkip, option.OptionType())
+	assert.Equal(t, int64(20), skip.Skip())
+}
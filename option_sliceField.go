@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TypeSliceField is the token that designates the "slice field" query option
+const TypeSliceField = "SLICEFIELD"
+
+// SliceFieldOption is a query option that returns only part of an array field,
+// using a $slice projection.
+type SliceFieldOption struct {
+	Field string // The name of the array field
+	Skip  *int   // The number of elements to skip, if any
+	Limit int    // The number of elements to return
+}
+
+// SliceField returns a query option that returns only the first `limit`
+// elements of an array field.  A negative limit returns the last elements
+// instead.
+func SliceField(field string, limit int) dataOption.Option {
+	return SliceFieldOption{
+		Field: field,
+		Limit: limit,
+	}
+}
+
+// SliceFieldRange returns a query option that returns `limit` elements of an
+// array field, starting after the first `skip` elements.  A negative skip
+// counts from the end of the array.
+func SliceFieldRange(field string, skip int, limit int) dataOption.Option {
+	return SliceFieldOption{
+		Field: field,
+		Skip:  pointerTo(skip),
+		Limit: limit,
+	}
+}
+
+// OptionType identifies this object as a query option
+func (option SliceFieldOption) OptionType() string {
+	return TypeSliceField
+}
+
+// Projection returns the mongodb projection for this option
+func (option SliceFieldOption) Projection() bson.M {
+
+	if option.Skip == nil {
+		return bson.M{"$slice": option.Limit}
+	}
+
+	return bson.M{"$slice": bson.A{*option.Skip, option.Limit}}
+}
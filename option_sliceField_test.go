@@ -0,0 +1,34 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSliceField(t *testing.T) {
+
+	option := SliceField("comments", -5)
+
+	slice, ok := option.(SliceFieldOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeSliceField, option.OptionType())
+	assert.Equal(t, "comments", slice.Field)
+	assert.Nil(t, slice.Skip)
+	assert.Equal(t, bson.M{"$slice": -5}, slice.Projection())
+}
+
+func TestSliceFieldRange(t *testing.T) {
+
+	option := SliceFieldRange("comments", 10, 5)
+
+	slice, ok := option.(SliceFieldOption)
+	require.True(t, ok)
+
+	require.NotNil(t, slice.Skip)
+	assert.Equal(t, 10, *slice.Skip)
+	assert.Equal(t, bson.M{"$slice": bson.A{10, 5}}, slice.Projection())
+}
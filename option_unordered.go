@@ -0,0 +1,24 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeUnordered is the token that designates the "unordered" bulk write option
+const TypeUnordered = "UNORDERED"
+
+// UnorderedOption is a bulk write option that lets the server apply writes in
+// any order, and continue past individual failures.
+type UnorderedOption struct{}
+
+// Unordered returns a bulk write option that applies writes in any order and
+// keeps going when an individual write fails.  By default, bulk writes are
+// ordered, and stop at the first failure.
+func Unordered() dataOption.Option {
+	return UnorderedOption{}
+}
+
+// OptionType identifies this object as a query option
+func (option UnorderedOption) OptionType() string {
+	return TypeUnordered
+}
@@ -0,0 +1,18 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnordered(t *testing.T) {
+
+	option := Unordered()
+
+	_, ok := option.(UnorderedOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeUnordered, option.OptionType())
+}
@@ -0,0 +1,34 @@
+package mongodb
+
+import (
+	dataOption "github.com/benpate/data/option"
+)
+
+// TypeUpsert is the token that designates the "upsert" update option
+const TypeUpsert = "UPSERT"
+
+// UpsertOption is an update option that inserts a new document when no
+// document matches the criteria.
+type UpsertOption struct{}
+
+// Upsert returns an update option that inserts a new document when no
+// document matches the criteria.  The new document contains the equality
+// fields from the criteria, with the changes applied on top of them.
+func Upsert() dataOption.Option {
+	return UpsertOption{}
+}
+
+// OptionType identifies this object as a query option
+func (option UpsertOption) OptionType() string {
+	return TypeUpsert
+}
+
+// hasUpsert returns TRUE if the options include an UpsertOption.
+func hasUpsert(options ...dataOption.Option) bool {
+	for _, option := range options {
+		if _, ok := option.(UpsertOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsert(t *testing.T) {
+
+	option := Upsert()
+
+	_, ok := option.(UpsertOption)
+	require.True(t, ok)
+
+	assert.Equal(t, TypeUpsert, option.OptionType())
+}
+
+func TestHasUpsert(t *testing.T) {
+	assert.False(t, hasUpsert())
+	assert.False(t, hasUpsert(ReturnAfter()))
+	assert.True(t, hasUpsert(ReturnAfter(), Upsert()))
+}
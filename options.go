@@ -1,21 +1,28 @@
 package mongodb
 
 import (
+	"time"
+
 	dataOption "github.com/benpate/data/option"
+	"github.com/benpate/derp"
 	bson "go.mongodb.org/mongo-driver/bson"
 	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // findOptions translates the standard data options into mongodb FindOptions for
 // a multi-row query.  It returns nil when no options are provided.
-func findOptions(options ...dataOption.Option) *mongoOptions.FindOptions {
+func findOptions(options ...dataOption.Option) (*mongoOptions.FindOptions, error) {
+
+	const location = "data-mongo.findOptions"
 
 	if len(options) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	result := mongoOptions.Find()
 
+	var sort bson.D
+
 	for _, option := range options {
 
 		switch opt := option.(type) {
@@ -28,48 +35,279 @@ func findOptions(options ...dataOption.Option) *mongoOptions.FindOptions {
 				result.SetLimit(opt.MaxRows())
 			}
 
-		case dataOption.FieldsOption:
-			result.SetProjection(fieldsProjection(opt.Fields()))
+		case SkipOption:
+			if opt > 0 {
+				result.SetSkip(opt.Skip())
+			}
 
 		case dataOption.SortOption:
-			result.SetSort(bson.D{{Key: opt.FieldName, Value: sortDirection(opt.Direction)}})
+			sort = appendSort(sort, opt)
 
-		case dataOption.CaseSensitiveOption:
-			result.SetCollation(caseCollation(opt.CaseSensitive()))
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
+
+		case MaxTimeOption:
+			result.MaxTime = maxTime(opt)
 		}
 	}
 
-	return result
+	projection, err := projectionDocument(options...)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid projection")
+	}
+
+	if len(projection) > 0 {
+		result.SetProjection(projection)
+	}
+
+	if len(sort) > 0 {
+		result.SetSort(sort)
+	}
+
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
+	return result, nil
 }
 
 // findOneOptions translates the standard data options into mongodb FindOneOptions.
-// Only Fields and CaseSensitive are meaningful when loading a single row.
-func findOneOptions(options ...dataOption.Option) *mongoOptions.FindOneOptions {
+// Multi-row options like MaxRows and Skip are ignored when loading a single
+// row.  Sort chooses which document is loaded when several match.
+func findOneOptions(options ...dataOption.Option) (*mongoOptions.FindOneOptions, error) {
+
+	const location = "data-mongo.findOneOptions"
 
 	if len(options) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	result := mongoOptions.FindOne()
 
+	var sort bson.D
+
 	for _, option := range options {
 
 		switch opt := option.(type) {
 
-		case dataOption.FieldsOption:
-			result.SetProjection(fieldsProjection(opt.Fields()))
+		case dataOption.SortOption:
+			sort = appendSort(sort, opt)
 
-		case dataOption.CaseSensitiveOption:
-			result.SetCollation(caseCollation(opt.CaseSensitive()))
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
+
+		case MaxTimeOption:
+			result.MaxTime = maxTime(opt)
+		}
+	}
+
+	projection, err := projectionDocument(options...)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid projection")
+	}
+
+	if len(projection) > 0 {
+		result.SetProjection(projection)
+	}
+
+	if len(sort) > 0 {
+		result.SetSort(sort)
+	}
+
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
+	return result, nil
+}
+
+// findOneAndUpdateOptions translates the standard data options into mongodb
+// FindOneAndUpdateOptions.  Sort chooses which document is modified when
+// several match, and ReturnDocument chooses which version is returned.
+func findOneAndUpdateOptions(options ...dataOption.Option) (*mongoOptions.FindOneAndUpdateOptions, error) {
+
+	const location = "data-mongo.findOneAndUpdateOptions"
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	result := mongoOptions.FindOneAndUpdate()
+
+	var sort bson.D
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case dataOption.SortOption:
+			sort = appendSort(sort, opt)
+
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
+
+		case MaxTimeOption:
+			result.MaxTime = maxTime(opt)
+
+		case ReturnDocumentOption:
+			result.SetReturnDocument(returnDocument(opt.After()))
+
+		case UpsertOption:
+			result.SetUpsert(true)
+		}
+	}
+
+	projection, err := projectionDocument(options...)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid projection")
+	}
+
+	if len(projection) > 0 {
+		result.SetProjection(projection)
+	}
+
+	if len(sort) > 0 {
+		result.SetSort(sort)
+	}
+
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
+	return result, nil
+}
+
+// updateOptions translates the standard data options that are meaningful to an
+// in-place update into mongodb UpdateOptions.
+func updateOptions(options ...dataOption.Option) *mongoOptions.UpdateOptions {
+
+	if len(options) == 0 {
+		return nil
+	}
+
+	result := mongoOptions.Update()
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
+
+		case UpsertOption:
+			result.SetUpsert(true)
+		}
+	}
+
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
+	return result
+}
+
+// deleteOptions translates the standard data options into mongodb
+// DeleteOptions.
+func deleteOptions(options ...dataOption.Option) *mongoOptions.DeleteOptions {
+
+	if len(options) == 0 {
+		return nil
+	}
+
+	result := mongoOptions.Delete()
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
 		}
 	}
 
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
 	return result
 }
 
+// findOneAndDeleteOptions translates the standard data options into mongodb
+// FindOneAndDeleteOptions.  Sort chooses which document is deleted when
+// several match.
+func findOneAndDeleteOptions(options ...dataOption.Option) (*mongoOptions.FindOneAndDeleteOptions, error) {
+
+	const location = "data-mongo.findOneAndDeleteOptions"
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	result := mongoOptions.FindOneAndDelete()
+
+	var sort bson.D
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case dataOption.SortOption:
+			sort = appendSort(sort, opt)
+
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
+
+		case MaxTimeOption:
+			result.MaxTime = maxTime(opt)
+		}
+	}
+
+	projection, err := projectionDocument(options...)
+
+	if err != nil {
+		return nil, derp.Wrap(err, location, "Invalid projection")
+	}
+
+	if len(projection) > 0 {
+		result.SetProjection(projection)
+	}
+
+	if len(sort) > 0 {
+		result.SetSort(sort)
+	}
+
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
+	return result, nil
+}
+
 // countOptions translates the standard data options that are meaningful to a
-// count into mongodb CountOptions.  Only MaxRows (Limit) and CaseSensitive
-// (Collation) affect a count; Fields and Sort are intentionally ignored.
+// count into mongodb CountOptions.  Only MaxRows (Limit), Skip, MaxTime, Hint,
+// Comment, Collate and CaseSensitive (Collation) affect a count; projections
+// and Sort are intentionally ignored.
 func countOptions(options ...dataOption.Option) *mongoOptions.CountOptions {
 
 	if len(options) == 0 {
@@ -87,42 +325,228 @@ func countOptions(options ...dataOption.Option) *mongoOptions.CountOptions {
 				result.SetLimit(opt.MaxRows())
 			}
 
-		case dataOption.CaseSensitiveOption:
-			result.SetCollation(caseCollation(opt.CaseSensitive()))
+		case SkipOption:
+			if opt > 0 {
+				result.SetSkip(opt.Skip())
+			}
+
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
+
+		case MaxTimeOption:
+			result.MaxTime = maxTime(opt)
 		}
 	}
 
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
+	}
+
 	return result
 }
 
-// caseCollation returns the mongodb Collation implementing the given case
-// sensitivity: Strength 3 is case-sensitive, Strength 2 is case-insensitive.
-func caseCollation(caseSensitive bool) *mongoOptions.Collation {
+// totalOptions translates the standard data options into the mongodb
+// CountOptions used to count every record that a query matches.  Unlike
+// countOptions, MaxRows and Skip are ignored because the total is not limited
+// to a single page of results.
+func totalOptions(options ...dataOption.Option) *mongoOptions.CountOptions {
+
+	result := mongoOptions.Count()
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case HintOption:
+			result.SetHint(opt.Index)
+
+		case CommentOption:
+			result.SetComment(opt.Comment())
 
-	strength := 2
-	if caseSensitive {
-		strength = 3
+		case MaxTimeOption:
+			result.MaxTime = maxTime(opt)
+		}
 	}
 
-	return &mongoOptions.Collation{
-		Locale:   "en",
-		Strength: strength,
+	if collation := queryCollation(options...); collation != nil {
+		result.SetCollation(collation)
 	}
+
+	return result
+}
+
+// bulkWriteOptions translates the options that are meaningful to a bulk
+// write into mongodb BulkWriteOptions.  Writes are ordered unless the
+// Unordered option is present.
+func bulkWriteOptions(options ...dataOption.Option) *mongoOptions.BulkWriteOptions {
+
+	result := mongoOptions.BulkWrite().SetOrdered(true)
+
+	for _, option := range options {
+		if _, ok := option.(UnorderedOption); ok {
+			result.SetOrdered(false)
+		}
+	}
+
+	return result
+}
+
+// queryCollation returns the mongodb Collation for the Collate and
+// CaseSensitive options, or nil if neither is present.  CaseSensitive sets the
+// strength of the collation, or of the default collation if there is no
+// Collate option.
+func queryCollation(options ...dataOption.Option) *mongoOptions.Collation {
+
+	var collation *Collation
+	var caseSensitive *bool
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case CollateOption:
+			collation = pointerTo(opt.Collation())
+
+		case dataOption.CaseSensitiveOption:
+			caseSensitive = pointerTo(opt.CaseSensitive())
+		}
+	}
+
+	if (collation == nil) && (caseSensitive == nil) {
+		return nil
+	}
+
+	if collation == nil {
+		collation = &Collation{}
+	}
+
+	if caseSensitive != nil {
+		*collation = collation.withCaseSensitive(*caseSensitive)
+	}
+
+	return collation.mongo()
+}
+
+// projectionDocument builds a mongodb projection from the Fields,
+// ExcludeFields, SliceField and ElemMatchField options, skipping any empty
+// field names.  A later option for the same field replaces an earlier one.  It
+// returns a 400 error if the projection cannot be translated exactly, or if it
+// mixes included and excluded fields (other than excluding `_id`), which
+// MongoDB does not allow.
+func projectionDocument(options ...dataOption.Option) (bson.D, error) {
+
+	const location = "data-mongo.projectionDocument"
+
+	var projection bson.D
+
+	for _, option := range options {
+
+		switch opt := option.(type) {
+
+		case dataOption.FieldsOption:
+			for _, field := range opt.Fields() {
+				projection = appendProjection(projection, field, 1)
+			}
+
+		case ExcludeFieldsOption:
+			for _, field := range opt.Fields() {
+				projection = appendProjection(projection, field, 0)
+			}
+
+		case SliceFieldOption:
+			projection = appendProjection(projection, opt.Field, opt.Projection())
+
+		case ElemMatchFieldOption:
+			elemMatch, err := opt.Projection()
+
+			if err != nil {
+				return nil, derp.Wrap(err, location, "Invalid ElemMatchField option")
+			}
+
+			projection = appendProjection(projection, opt.Field, elemMatch)
+		}
+	}
+
+	included := ""
+	excluded := ""
+
+	for _, element := range projection {
+
+		if element.Key == "_id" {
+			continue
+		}
+
+		switch element.Value {
+		case 1:
+			included = element.Key
+		case 0:
+			excluded = element.Key
+		}
+	}
+
+	if (included != "") && (excluded != "") {
+		return nil, derp.BadRequest(location, "Projection cannot both include and exclude fields, except to exclude _id", included, excluded)
+	}
+
+	return projection, nil
+}
+
+// appendProjection adds a field to a projection, replacing any earlier value
+// for the same field.  Empty field names are ignored.
+func appendProjection(projection bson.D, field string, value any) bson.D {
+
+	if field == "" {
+		return projection
+	}
+
+	for index := range projection {
+		if projection[index].Key == field {
+			projection[index].Value = value
+			return projection
+		}
+	}
+
+	return append(projection, bson.E{Key: field, Value: value})
+}
+
+// returnDocument maps a ReturnDocument flag onto the mongodb constants.
+func returnDocument(after bool) mongoOptions.ReturnDocument {
+	if after {
+		return mongoOptions.After
+	}
+
+	return mongoOptions.Before
+}
+
+// maxTime returns the server-side time limit for a MaxTime option, or nil if
+// the option removes the limit.
+func maxTime(option MaxTimeOption) *time.Duration {
+
+	if option <= 0 {
+		return nil
+	}
+
+	return pointerTo(option.MaxTime())
 }
 
-// fieldsProjection builds a mongodb projection including the named fields,
-// skipping any empty field names.
-func fieldsProjection(fields []string) bson.D {
+// appendSort adds a sort option to a compound sort, so that multiple sort
+// options are applied in order, each one breaking ties in the ones before it.
+// Sorting the same field again changes its direction without moving it.
+func appendSort(sort bson.D, option dataOption.SortOption) bson.D {
 
-	projection := make(bson.D, 0, len(fields))
+	direction := sortDirection(option.Direction)
 
-	for _, field := range fields {
-		if field != "" {
-			projection = append(projection, bson.E{Key: field, Value: 1})
+	for index := range sort {
+		if sort[index].Key == option.FieldName {
+			sort[index].Value = direction
+			return sort
 		}
 	}
 
-	return projection
+	return append(sort, bson.E{Key: option.FieldName, Value: direction})
 }
 
 // sortDirection maps a data sort direction onto the mongodb convention: -1 for
@@ -2,24 +2,56 @@ package mongodb
 
 import (
 	"testing"
+	"time"
 
 	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
 )
 
+func mustFindOptions(t *testing.T, options ...option.Option) *mongoOptions.FindOptions {
+	t.Helper()
+	result, err := findOptions(options...)
+	require.NoError(t, err)
+	return result
+}
+
+func mustFindOneOptions(t *testing.T, options ...option.Option) *mongoOptions.FindOneOptions {
+	t.Helper()
+	result, err := findOneOptions(options...)
+	require.NoError(t, err)
+	return result
+}
+
+func mustFindOneAndUpdateOptions(t *testing.T, options ...option.Option) *mongoOptions.FindOneAndUpdateOptions {
+	t.Helper()
+	result, err := findOneAndUpdateOptions(options...)
+	require.NoError(t, err)
+	return result
+}
+
+func mustFindOneAndDeleteOptions(t *testing.T, options ...option.Option) *mongoOptions.FindOneAndDeleteOptions {
+	t.Helper()
+	result, err := findOneAndDeleteOptions(options...)
+	require.NoError(t, err)
+	return result
+}
+
 /******************************************
  * findOptions()
  ******************************************/
 
 // With no options, findOptions returns nil so the driver uses its defaults.
 func TestFindOptions_Empty(t *testing.T) {
-	assert.Nil(t, findOptions())
+	assert.Nil(t, mustFindOptions(t))
 }
 
 func TestFindOptions_FirstRow(t *testing.T) {
-	result := findOptions(option.FirstRow())
+	result := mustFindOptions(t, option.FirstRow())
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Limit)
@@ -27,7 +59,7 @@ func TestFindOptions_FirstRow(t *testing.T) {
 }
 
 func TestFindOptions_MaxRows(t *testing.T) {
-	result := findOptions(option.MaxRows(25))
+	result := mustFindOptions(t, option.MaxRows(25))
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Limit)
@@ -36,14 +68,14 @@ func TestFindOptions_MaxRows(t *testing.T) {
 
 // A MaxRows value of zero (or less) means "no limit", so Limit stays unset.
 func TestFindOptions_MaxRowsZero(t *testing.T) {
-	result := findOptions(option.MaxRows(0))
+	result := mustFindOptions(t, option.MaxRows(0))
 
 	require.NotNil(t, result)
 	assert.Nil(t, result.Limit)
 }
 
 func TestFindOptions_Fields(t *testing.T) {
-	result := findOptions(option.Fields("name", "age"))
+	result := mustFindOptions(t, option.Fields("name", "age"))
 
 	require.NotNil(t, result)
 	assert.Equal(t, bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}}, result.Projection)
@@ -51,28 +83,52 @@ func TestFindOptions_Fields(t *testing.T) {
 
 // Empty field names are skipped when building the projection.
 func TestFindOptions_FieldsSkipsEmpty(t *testing.T) {
-	result := findOptions(option.Fields("name", "", "age"))
+	result := mustFindOptions(t, option.Fields("name", "", "age"))
 
 	require.NotNil(t, result)
 	assert.Equal(t, bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}}, result.Projection)
 }
 
 func TestFindOptions_SortAscending(t *testing.T) {
-	result := findOptions(option.SortAsc("name"))
+	result := mustFindOptions(t, option.SortAsc("name"))
 
 	require.NotNil(t, result)
 	assert.Equal(t, bson.D{{Key: "name", Value: 1}}, result.Sort)
 }
 
+// Multiple sort options accumulate into a compound sort, in order.
+func TestFindOptions_SortCompound(t *testing.T) {
+	result := mustFindOptions(t, option.SortAsc("lastName"), option.MaxRows(10), option.SortDesc("age"), option.SortAsc("_id"))
+
+	require.NotNil(t, result)
+	assert.Equal(t, bson.D{{Key: "lastName", Value: 1}, {Key: "age", Value: -1}, {Key: "_id", Value: 1}}, result.Sort)
+}
+
+// Sorting the same field again changes its direction without moving it.
+func TestFindOptions_SortRepeated(t *testing.T) {
+	result := mustFindOptions(t, option.SortAsc("age"), option.SortAsc("name"), option.SortDesc("age"))
+
+	require.NotNil(t, result)
+	assert.Equal(t, bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}}, result.Sort)
+}
+
+// The FindOneAndX options accumulate sorts too.
+func TestFindOneAndXOptions_SortCompound(t *testing.T) {
+	expected := bson.D{{Key: "age", Value: 1}, {Key: "name", Value: -1}}
+
+	assert.Equal(t, expected, mustFindOneAndUpdateOptions(t, option.SortAsc("age"), option.SortDesc("name")).Sort)
+	assert.Equal(t, expected, mustFindOneAndDeleteOptions(t, option.SortAsc("age"), option.SortDesc("name")).Sort)
+}
+
 func TestFindOptions_SortDescending(t *testing.T) {
-	result := findOptions(option.SortDesc("name"))
+	result := mustFindOptions(t, option.SortDesc("name"))
 
 	require.NotNil(t, result)
 	assert.Equal(t, bson.D{{Key: "name", Value: -1}}, result.Sort)
 }
 
 func TestFindOptions_CaseSensitive(t *testing.T) {
-	result := findOptions(option.CaseSensitive(true))
+	result := mustFindOptions(t, option.CaseSensitive(true))
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Collation)
@@ -81,7 +137,7 @@ func TestFindOptions_CaseSensitive(t *testing.T) {
 }
 
 func TestFindOptions_CaseInsensitive(t *testing.T) {
-	result := findOptions(option.CaseSensitive(false))
+	result := mustFindOptions(t, option.CaseSensitive(false))
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Collation)
@@ -91,7 +147,7 @@ func TestFindOptions_CaseInsensitive(t *testing.T) {
 
 // Multiple options should all be applied to the same result.
 func TestFindOptions_Combined(t *testing.T) {
-	result := findOptions(option.MaxRows(10), option.SortDesc("age"), option.Fields("name"))
+	result := mustFindOptions(t, option.MaxRows(10), option.SortDesc("age"), option.Fields("name"))
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Limit)
@@ -100,30 +156,51 @@ func TestFindOptions_Combined(t *testing.T) {
 	assert.Equal(t, bson.D{{Key: "name", Value: 1}}, result.Projection)
 }
 
+// Options that are handled by the Collection itself are ignored by the
+// standard option translators.
+func TestFindOptions_IgnoresCollectionOptions(t *testing.T) {
+
+	tests := map[string]option.Option{
+		"IncludeDeleted": IncludeDeleted(),
+		"SkipInvalid":    SkipInvalid(),
+		"CountTotal":     CountTotal(),
+	}
+
+	for name, opt := range tests {
+
+		result := mustFindOptions(t, opt)
+
+		require.NotNil(t, result, name)
+		assert.Nil(t, result.Limit, name)
+		assert.Nil(t, result.Sort, name)
+		assert.Nil(t, result.Projection, name)
+	}
+}
+
 /******************************************
  * findOneOptions()
  ******************************************/
 
 func TestFindOneOptions_Empty(t *testing.T) {
-	assert.Nil(t, findOneOptions())
+	assert.Nil(t, mustFindOneOptions(t))
 }
 
 func TestFindOneOptions_Fields(t *testing.T) {
-	result := findOneOptions(option.Fields("name", "age"))
+	result := mustFindOneOptions(t, option.Fields("name", "age"))
 
 	require.NotNil(t, result)
 	assert.Equal(t, bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}}, result.Projection)
 }
 
 func TestFindOneOptions_FieldsSkipsEmpty(t *testing.T) {
-	result := findOneOptions(option.Fields("", "age"))
+	result := mustFindOneOptions(t, option.Fields("", "age"))
 
 	require.NotNil(t, result)
 	assert.Equal(t, bson.D{{Key: "age", Value: 1}}, result.Projection)
 }
 
 func TestFindOneOptions_CaseSensitive(t *testing.T) {
-	result := findOneOptions(option.CaseSensitive(true))
+	result := mustFindOneOptions(t, option.CaseSensitive(true))
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Collation)
@@ -131,22 +208,30 @@ func TestFindOneOptions_CaseSensitive(t *testing.T) {
 }
 
 func TestFindOneOptions_CaseInsensitive(t *testing.T) {
-	result := findOneOptions(option.CaseSensitive(false))
+	result := mustFindOneOptions(t, option.CaseSensitive(false))
 
 	require.NotNil(t, result)
 	require.NotNil(t, result.Collation)
 	assert.Equal(t, 2, result.Collation.Strength)
 }
 
-// Options that only apply to multi-row queries (like Sort) are ignored here,
+// Options that only apply to multi-row queries (like MaxRows) are ignored here,
 // but must not prevent a non-nil result from being returned.
 func TestFindOneOptions_IgnoresUnsupported(t *testing.T) {
-	result := findOneOptions(option.SortAsc("name"))
+	result := mustFindOneOptions(t, option.MaxRows(10))
 
 	require.NotNil(t, result)
 	assert.Nil(t, result.Sort)
 }
 
+// Sort chooses which document is loaded when several match.
+func TestFindOneOptions_Sort(t *testing.T) {
+	result := mustFindOneOptions(t, option.SortDesc("age"), option.SortAsc("name"))
+
+	require.NotNil(t, result)
+	assert.Equal(t, bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}}, result.Sort)
+}
+
 /******************************************
  * countOptions()
  ******************************************/
@@ -197,6 +282,122 @@ func TestCountOptions_IgnoresUnsupported(t *testing.T) {
 	assert.Nil(t, result.Collation)
 }
 
+/******************************************
+ * findOneAndUpdateOptions()
+ ******************************************/
+
+func TestFindOneAndUpdateOptions_Empty(t *testing.T) {
+	assert.Nil(t, mustFindOneAndUpdateOptions(t))
+}
+
+func TestFindOneAndUpdateOptions(t *testing.T) {
+	result := mustFindOneAndUpdateOptions(t, option.SortAsc("age"), option.Fields("name"), option.CaseSensitive(true), ReturnAfter())
+
+	require.NotNil(t, result)
+	assert.Equal(t, bson.D{{Key: "age", Value: 1}}, result.Sort)
+	assert.Equal(t, bson.D{{Key: "name", Value: 1}}, result.Projection)
+	require.NotNil(t, result.Collation)
+	assert.Equal(t, 3, result.Collation.Strength)
+	require.NotNil(t, result.ReturnDocument)
+	assert.Equal(t, mongoOptions.After, *result.ReturnDocument)
+}
+
+func TestFindOneAndUpdateOptions_Upsert(t *testing.T) {
+	result := mustFindOneAndUpdateOptions(t, Upsert())
+
+	require.NotNil(t, result.Upsert)
+	assert.True(t, *result.Upsert)
+}
+
+func TestFindOneAndUpdateOptions_ReturnBefore(t *testing.T) {
+	result := mustFindOneAndUpdateOptions(t, ReturnBefore())
+
+	require.NotNil(t, result.ReturnDocument)
+	assert.Equal(t, mongoOptions.Before, *result.ReturnDocument)
+}
+
+/******************************************
+ * updateOptions()
+ ******************************************/
+
+func TestUpdateOptions_Empty(t *testing.T) {
+	assert.Nil(t, updateOptions())
+}
+
+func TestUpdateOptions(t *testing.T) {
+	result := updateOptions(Upsert(), option.CaseSensitive(false))
+
+	require.NotNil(t, result)
+	require.NotNil(t, result.Upsert)
+	assert.True(t, *result.Upsert)
+	require.NotNil(t, result.Collation)
+	assert.Equal(t, 2, result.Collation.Strength)
+}
+
+// Options that have no meaning for an update are ignored.
+func TestUpdateOptions_IgnoresUnsupported(t *testing.T) {
+	result := updateOptions(option.SortAsc("name"), ReturnAfter())
+
+	require.NotNil(t, result)
+	assert.Nil(t, result.Upsert)
+	assert.Nil(t, result.Collation)
+}
+
+/******************************************
+ * deleteOptions()
+ ******************************************/
+
+func TestDeleteOptions_Empty(t *testing.T) {
+	assert.Nil(t, deleteOptions())
+}
+
+func TestDeleteOptions(t *testing.T) {
+	result := deleteOptions(Collate(Collation{Locale: "fr"}), Hint("name_1"), Comment("cleanup"))
+
+	require.NotNil(t, result)
+	require.NotNil(t, result.Collation)
+	assert.Equal(t, "fr", result.Collation.Locale)
+	assert.Equal(t, "name_1", result.Hint)
+	assert.Equal(t, "cleanup", result.Comment)
+}
+
+/******************************************
+ * findOneAndDeleteOptions()
+ ******************************************/
+
+func TestFindOneAndDeleteOptions_Empty(t *testing.T) {
+	assert.Nil(t, mustFindOneAndDeleteOptions(t))
+}
+
+func TestFindOneAndDeleteOptions(t *testing.T) {
+	result := mustFindOneAndDeleteOptions(t, option.SortDesc("age"), option.Fields("name"), option.CaseSensitive(false))
+
+	require.NotNil(t, result)
+	assert.Equal(t, bson.D{{Key: "age", Value: -1}}, result.Sort)
+	assert.Equal(t, bson.D{{Key: "name", Value: 1}}, result.Projection)
+	require.NotNil(t, result.Collation)
+	assert.Equal(t, 2, result.Collation.Strength)
+}
+
+/******************************************
+ * bulkWriteOptions()
+ ******************************************/
+
+// Bulk writes are ordered by default.
+func TestBulkWriteOptions_Default(t *testing.T) {
+	result := bulkWriteOptions()
+
+	require.NotNil(t, result.Ordered)
+	assert.True(t, *result.Ordered)
+}
+
+func TestBulkWriteOptions_Unordered(t *testing.T) {
+	result := bulkWriteOptions(option.MaxRows(10), Unordered())
+
+	require.NotNil(t, result.Ordered)
+	assert.False(t, *result.Ordered)
+}
+
 /******************************************
  * sortDirection()
  ******************************************/
@@ -206,3 +407,180 @@ func TestSortDirection(t *testing.T) {
 	assert.Equal(t, -1, sortDirection(option.SortDirectionDescending))
 	assert.Equal(t, 1, sortDirection("anything else defaults to ascending"))
 }
+
+/******************************************
+ * totalOptions()
+ ******************************************/
+
+// The total ignores MaxRows but keeps the collation.
+func TestTotalOptions(t *testing.T) {
+
+	result := totalOptions(option.MaxRows(10), option.CaseSensitive(false))
+
+	require.NotNil(t, result)
+	assert.Nil(t, result.Limit)
+	require.NotNil(t, result.Collation)
+	assert.Equal(t, 2, result.Collation.Strength)
+}
+
+// Skip is applied to queries and counts, but not to totals.
+func TestOptions_Skip(t *testing.T) {
+
+	find := mustFindOptions(t, Skip(20))
+	require.NotNil(t, find.Skip)
+	assert.Equal(t, int64(20), *find.Skip)
+
+	count := countOptions(Skip(20))
+	require.NotNil(t, count.Skip)
+	assert.Equal(t, int64(20), *count.Skip)
+
+	assert.Nil(t, totalOptions(Skip(20)).Skip)
+
+	// Zero and negative values are ignored
+	assert.Nil(t, mustFindOptions(t, Skip(0)).Skip)
+	assert.Nil(t, mustFindOptions(t, Skip(-1)).Skip)
+}
+
+/******************************************
+ * projectionDocument()
+ ******************************************/
+
+// Projection options accumulate in order, and later options replace earlier
+// ones for the same field.
+func TestProjectionDocument(t *testing.T) {
+
+	result, err := projectionDocument(
+		option.Fields("subject", "tags"),
+		SliceField("tags", 3),
+		option.MaxRows(10),
+		ElemMatchField("recipients", exp.Equal("role", "admin")),
+		ExcludeFields("_id"),
+	)
+
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.D{
+		{Key: "subject", Value: 1},
+		{Key: "tags", Value: bson.M{"$slice": 3}},
+		{Key: "recipients", Value: bson.M{"$elemMatch": bson.M{"role": bson.M{"$eq": "admin"}}}},
+		{Key: "_id", Value: 0},
+	}, result)
+
+	result, err = projectionDocument(option.SortAsc("name"))
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+// Mixing included and excluded fields is refused, except to exclude `_id`.
+func TestProjectionDocument_Mixed(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options []option.Option
+		valid   bool
+	}{
+		{"include only", []option.Option{option.Fields("name", "age")}, true},
+		{"exclude only", []option.Option{ExcludeFields("body", "history")}, true},
+		{"include and exclude _id", []option.Option{option.Fields("name"), ExcludeFields("_id")}, true},
+		{"exclude and include _id", []option.Option{ExcludeFields("body"), option.Fields("_id")}, true},
+		{"exclude with slice", []option.Option{ExcludeFields("body"), SliceField("tags", 3)}, true},
+		{"replaced by a later option", []option.Option{option.Fields("body"), ExcludeFields("body")}, true},
+		{"include and exclude", []option.Option{option.Fields("name"), ExcludeFields("body")}, false},
+		{"exclude and include", []option.Option{ExcludeFields("body", "_id"), option.Fields("name")}, false},
+	}
+
+	for _, test := range tests {
+
+		_, err := projectionDocument(test.options...)
+
+		if test.valid {
+			assert.NoError(t, err, test.name)
+		} else {
+			assert.True(t, derp.IsBadRequest(err), test.name)
+		}
+
+		_, err = findOptions(test.options...)
+		assert.Equal(t, test.valid, err == nil, test.name)
+	}
+}
+
+// Every single-document operation honors the projection options.
+func TestProjectionOptions_FindOne(t *testing.T) {
+
+	expected := bson.D{{Key: "body", Value: 0}}
+
+	assert.Equal(t, expected, mustFindOneOptions(t, ExcludeFields("body")).Projection)
+	assert.Equal(t, expected, mustFindOneAndUpdateOptions(t, ExcludeFields("body")).Projection)
+	assert.Equal(t, expected, mustFindOneAndDeleteOptions(t, ExcludeFields("body")).Projection)
+}
+
+/******************************************
+ * MaxTime
+ ******************************************/
+
+// MaxTime is applied to every read, and a zero duration removes the limit.
+func TestOptions_MaxTime(t *testing.T) {
+
+	expected := pointerTo(2 * time.Second)
+
+	assert.Equal(t, expected, mustFindOptions(t, MaxTime(2*time.Second)).MaxTime)
+	assert.Equal(t, expected, mustFindOneOptions(t, MaxTime(2*time.Second)).MaxTime)
+	assert.Equal(t, expected, mustFindOneAndUpdateOptions(t, MaxTime(2*time.Second)).MaxTime)
+	assert.Equal(t, expected, mustFindOneAndDeleteOptions(t, MaxTime(2*time.Second)).MaxTime)
+	assert.Equal(t, expected, countOptions(MaxTime(2*time.Second)).MaxTime)
+	assert.Equal(t, expected, totalOptions(MaxTime(2*time.Second)).MaxTime)
+
+	// A later option overrides an earlier one
+	assert.Nil(t, mustFindOptions(t, MaxTime(2*time.Second), MaxTime(0)).MaxTime)
+}
+
+/******************************************
+ * queryCollation()
+ ******************************************/
+
+func TestQueryCollation(t *testing.T) {
+
+	// No collation unless one is requested
+	assert.Nil(t, queryCollation())
+	assert.Nil(t, queryCollation(option.SortAsc("name")))
+
+	// CaseSensitive alone uses the default locale
+	result := queryCollation(option.CaseSensitive(false))
+	require.NotNil(t, result)
+	assert.Equal(t, "en", result.Locale)
+	assert.Equal(t, 2, result.Strength)
+
+	// Collate sets every field
+	result = queryCollation(Collate(Collation{Locale: "de", Strength: 1, NumericOrdering: true, Alternate: "shifted"}))
+	require.NotNil(t, result)
+	assert.Equal(t, &mongoOptions.Collation{Locale: "de", Strength: 1, NumericOrdering: true, Alternate: "shifted"}, result)
+
+	// CaseSensitive overrides the strength of the collation, in either order
+	for _, options := range [][]option.Option{
+		{Collate(Collation{Locale: "tr", Strength: 1}), option.CaseSensitive(true)},
+		{option.CaseSensitive(true), Collate(Collation{Locale: "tr", Strength: 1})},
+	} {
+		result = queryCollation(options...)
+		require.NotNil(t, result)
+		assert.Equal(t, "tr", result.Locale)
+		assert.Equal(t, 3, result.Strength)
+	}
+
+	// A later Collate replaces an earlier one
+	result = queryCollation(Collate(Collation{Locale: "de"}), Collate(Collation{Locale: "ja"}))
+	assert.Equal(t, "ja", result.Locale)
+}
+
+// Every read and update uses the same collation.
+func TestQueryCollation_Options(t *testing.T) {
+
+	option := Collate(Collation{Locale: "de"})
+
+	assert.Equal(t, "de", mustFindOptions(t, option).Collation.Locale)
+	assert.Equal(t, "de", mustFindOneOptions(t, option).Collation.Locale)
+	assert.Equal(t, "de", countOptions(option).Collation.Locale)
+	assert.Equal(t, "de", totalOptions(option).Collation.Locale)
+	assert.Equal(t, "de", mustFindOneAndUpdateOptions(t, option).Collation.Locale)
+	assert.Equal(t, "de", mustFindOneAndDeleteOptions(t, option).Collation.Locale)
+	assert.Equal(t, "de", updateOptions(option).Collation.Locale)
+}
@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"math"
+
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+)
+
+// PageInfo describes a single page of query results
+type PageInfo struct {
+	Page        int64 `json:"page"`        // The (1-based) number of this page
+	PageSize    int64 `json:"pageSize"`    // The maximum number of records on each page
+	Total       int64 `json:"total"`       // The number of records that matched the query
+	TotalPages  int64 `json:"totalPages"`  // The number of pages needed to display every record
+	HasPrevious bool  `json:"hasPrevious"` // TRUE if there is a page before this one
+	HasNext     bool  `json:"hasNext"`     // TRUE if there is a page after this one
+}
+
+// newPageInfo calculates the metadata for a page of results
+func newPageInfo(page int64, pageSize int64, total int64) PageInfo {
+
+	// Round up without adding, which could overflow
+	totalPages := total / pageSize
+
+	if total%pageSize != 0 {
+		totalPages++
+	}
+
+	return PageInfo{
+		Page:        page,
+		PageSize:    pageSize,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasPrevious: page > 1,
+		HasNext:     page < totalPages,
+	}
+}
+
+// pageOptions returns the options that select a single page of results.  They
+// are appended after the caller's options so that they take precedence over
+// any MaxRows or Skip options that the caller provided.
+func pageOptions(page int64, pageSize int64, options ...option.Option) []option.Option {
+
+	result := make([]option.Option, 0, len(options)+2)
+	result = append(result, options...)
+	result = append(result, Skip((page-1)*pageSize), option.MaxRows(pageSize))
+
+	return result
+}
+
+// Page retrieves a single page of objects from the database into the target
+// slice, and returns the page metadata.  Pages are numbered from 1.  Include a
+// Sort option so that records do not move between pages.
+func (c Collection) Page(target any, criteria exp.Expression, page int64, pageSize int64, options ...option.Option) (PageInfo, error) {
+
+	const location = "data-mongo.Collection.Page"
+
+	if page < 1 {
+		return PageInfo{}, derp.BadRequest(location, "Page number must be 1 or greater", page)
+	}
+
+	if pageSize < 1 {
+		return PageInfo{}, derp.BadRequest(location, "Page size must be 1 or greater", pageSize)
+	}
+
+	// The number of rows to skip must not overflow
+	if page-1 > math.MaxInt64/pageSize {
+		return PageInfo{}, derp.BadRequest(location, "Page number is too large for the page size", page, pageSize)
+	}
+
+	// Count every matching record
+	criteriaBSON, err := c.filter(criteria, options...)
+
+	if err != nil {
+		return PageInfo{}, derp.Wrap(err, location, "Invalid criteria")
+	}
+
+	total, err := c.collection.CountDocuments(c.context, criteriaBSON, totalOptions(c.queryOptions(options...)...))
+
+	if err != nil {
+		return PageInfo{}, derp.Wrap(err, location, "Counting objects", criteriaBSON, derp.WithCode(queryErrorCode(err)))
+	}
+
+	// Load the requested page
+	if err := c.Query(target, criteria, pageOptions(page, pageSize, options...)...); err != nil {
+		return PageInfo{}, derp.Wrap(err, location, "Loading page", page, pageSize)
+	}
+
+	return newPageInfo(page, pageSize, total), nil
+}
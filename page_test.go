@@ -0,0 +1,95 @@
+package mongodb
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPageInfo(t *testing.T) {
+
+	// A middle page
+	assert.Equal(t, PageInfo{Page: 2, PageSize: 10, Total: 25, TotalPages: 3, HasPrevious: true, HasNext: true}, newPageInfo(2, 10, 25))
+
+	// The last page
+	assert.Equal(t, PageInfo{Page: 3, PageSize: 10, Total: 25, TotalPages: 3, HasPrevious: true, HasNext: false}, newPageInfo(3, 10, 25))
+
+	// An exact multiple of the page size
+	assert.Equal(t, int64(2), newPageInfo(1, 10, 20).TotalPages)
+
+	// No results at all
+	assert.Equal(t, PageInfo{Page: 1, PageSize: 10}, newPageInfo(1, 10, 0))
+
+	// Large values do not overflow
+	assert.Equal(t, int64(math.MaxInt64/2+1), newPageInfo(1, 2, math.MaxInt64).TotalPages)
+	assert.Equal(t, int64(1), newPageInfo(1, math.MaxInt64, math.MaxInt64).TotalPages)
+}
+
+// The page's Skip and MaxRows override any that the caller provided.
+func TestPageOptions(t *testing.T) {
+
+	result := mustFindOptions(t, pageOptions(3, 10, option.MaxRows(100), Skip(5), option.SortAsc("name"))...)
+
+	require.NotNil(t, result)
+	assert.Equal(t, int64(20), *result.Skip)
+	assert.Equal(t, int64(10), *result.Limit)
+	assert.NotNil(t, result.Sort)
+}
+
+func TestCollection_Page(t *testing.T) {
+
+	collection := getTestCollection(t)
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		require.NoError(t, collection.Save(newTestPerson(name, 1), "seed"))
+	}
+
+	result := make([]testPerson, 0)
+	info, err := collection.Page(&result, exp.All(), 2, 2, option.SortAsc("name"))
+	require.NoError(t, err)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "C", result[0].Name)
+	assert.Equal(t, "D", result[1].Name)
+	assert.Equal(t, PageInfo{Page: 2, PageSize: 2, Total: 5, TotalPages: 3, HasPrevious: true, HasNext: true}, info)
+}
+
+func TestCollection_Page_Invalid(t *testing.T) {
+
+	collection := Collection{}
+	result := make([]testPerson, 0)
+
+	_, err := collection.Page(&result, exp.All(), 0, 10)
+	assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+
+	_, err = collection.Page(&result, exp.All(), 1, 0)
+	assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+
+	// Pages that would skip past the largest row number
+	_, err = collection.Page(&result, exp.All(), math.MaxInt64, 2)
+	assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+
+	_, err = collection.Page(&result, exp.All(), 3, math.MaxInt64)
+	assert.Equal(t, http.StatusBadRequest, derp.ErrorCode(err))
+}
+
+func TestTypedCollection_Page(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+	for _, name := range []string{"A", "B", "C"} {
+		require.NoError(t, people.Save(newTestPerson(name, 1), "seed"))
+	}
+
+	result, info, err := people.Page(exp.All(), 2, 2, option.SortAsc("name"))
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "C", result[0].Name)
+	assert.False(t, info.HasNext)
+	assert.True(t, info.HasPrevious)
+}
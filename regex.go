@@ -0,0 +1,329 @@
+package mongodb
+
+import (
+	"regexp/syntax"
+	"strings"
+	"sync/atomic"
+
+	"github.com/benpate/derp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RegexMode controls which patterns the Regex operator accepts
+type RegexMode int32
+
+const (
+	// RegexSafe accepts patterns up to MaxRegexLength characters that use only
+	// constructs that cannot backtrack catastrophically.  This is the default.
+	RegexSafe RegexMode = iota
+
+	// RegexDisabled rejects every Regex predicate
+	RegexDisabled
+
+	// RegexUnrestricted passes every pattern through to the server unchecked
+	RegexUnrestricted
+)
+
+// MaxRegexLength is the longest pattern that RegexSafe mode accepts
+const MaxRegexLength = 256
+
+// MaxRegexRepeats is the largest number of variable-length quantifiers (such as
+// "*", "+", "?" or "{1,5}") that RegexSafe mode accepts in one pattern.  Each
+// one can multiply the backtracking work by the length of the subject, so this
+// limits the worst case to quadratic time.  Use MaxTime (or the WithMaxTime
+// setting) to bound that as well.
+const MaxRegexRepeats = 2
+
+// regexOptions are the pattern options that MongoDB supports
+const regexOptions = "imsx"
+
+// regexMode is the current RegexMode.  It is accessed atomically because
+// SetRegexMode may run concurrently with in-flight queries.
+var regexMode atomic.Int32
+
+// SetRegexMode configures which patterns the Regex operator accepts
+func SetRegexMode(mode RegexMode) {
+	regexMode.Store(int32(mode))
+}
+
+// regexBSON returns the $regex operator for a Regex predicate's value, after
+// checking the pattern according to the current RegexMode.
+func regexBSON(value any) (primitive.Regex, error) {
+
+	const location = "data-mongo.regexBSON"
+
+	var result primitive.Regex
+
+	switch typed := value.(type) {
+	case string:
+		result = primitive.Regex{Pattern: typed}
+	case primitive.Regex:
+		result = typed
+	default:
+		return result, derp.BadRequest(location, "Regex value must be a string or primitive.Regex", value)
+	}
+
+	for _, option := range result.Options {
+		if !strings.ContainsRune(regexOptions, option) {
+			return result, derp.BadRequest(location, "Unsupported regex option", string(option))
+		}
+	}
+
+	switch RegexMode(regexMode.Load()) {
+
+	case RegexUnrestricted:
+		return result, nil
+
+	case RegexSafe:
+
+		pattern := result.Pattern
+
+		// The server ignores whitespace and comments in extended mode, so
+		// check the pattern that it will actually run
+		if strings.ContainsRune(result.Options, 'x') {
+			if len(pattern) > MaxRegexLength {
+				return result, derp.BadRequest(location, "Regex pattern is too long", len(pattern), MaxRegexLength)
+			}
+			pattern = stripExtended(pattern)
+		}
+
+		if err := checkRegex(pattern); err != nil {
+			return result, derp.Wrap(err, location, "Unsafe regex pattern", result.Pattern)
+		}
+		return result, nil
+	}
+
+	return result, derp.BadRequest(location, "Regex operator is disabled.  Use SetRegexMode to enable it")
+}
+
+// checkRegex returns an error if a pattern is too long, uses constructs that
+// Go's linear-time engine does not support (such as backreferences and
+// lookarounds), or repeats a group that itself contains a repetition or an
+// alternation (such as "(a+)+" or "(a|ab)*").  Those are the constructs that
+// make MongoDB's backtracking engine take exponential time.  It also refuses
+// more than MaxRegexRepeats variable-length quantifiers, because adjacent ones
+// (such as ".*.*.*") take polynomial time.
+func checkRegex(pattern string) error {
+
+	const location = "data-mongo.checkRegex"
+
+	if len(pattern) > MaxRegexLength {
+		return derp.BadRequest(location, "Regex pattern is too long", len(pattern), MaxRegexLength)
+	}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+
+	if err != nil {
+		return derp.Wrap(err, location, "Regex pattern is not supported", derp.WithBadRequest())
+	}
+
+	if repeats := countRepeats(parsed); repeats > MaxRegexRepeats {
+		return derp.BadRequest(location, "Regex pattern has too many variable-length quantifiers", repeats, MaxRegexRepeats)
+	}
+
+	if hasNestedRepeat(parsed, false) {
+		return derp.BadRequest(location, "Regex pattern repeats a group that contains a repetition")
+	}
+
+	// The parser simplifies alternations (such as "a|a" into "a"), so they are
+	// checked in the original pattern instead
+	if hasRepeatedAlternation(pattern) {
+		return derp.BadRequest(location, "Regex pattern repeats a group that contains an alternation")
+	}
+
+	return nil
+}
+
+// countRepeats returns the number of quantifiers in a pattern that can match a
+// variable number of times.  Fixed repetitions (such as "a{3}") are not
+// counted, because they cannot backtrack.
+func countRepeats(node *syntax.Regexp) int {
+
+	result := 0
+
+	switch node.Op {
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		result++
+
+	case syntax.OpRepeat:
+		if node.Min != node.Max {
+			result++
+		}
+	}
+
+	for _, sub := range node.Sub {
+		result += countRepeats(sub)
+	}
+
+	return result
+}
+
+// hasNestedRepeat returns TRUE if a repetition contains another repetition.
+// insideRepeat is TRUE when an enclosing node repeats.
+func hasNestedRepeat(node *syntax.Regexp, insideRepeat bool) bool {
+
+	switch node.Op {
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+
+		if insideRepeat {
+			return true
+		}
+
+		// A bounded, optional match (such as "a?" or "a{0,1}") cannot repeat
+		insideRepeat = !((node.Op == syntax.OpQuest) || ((node.Op == syntax.OpRepeat) && (node.Max == 1)))
+	}
+
+	for _, sub := range node.Sub {
+		if hasNestedRepeat(sub, insideRepeat) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasRepeatedAlternation returns TRUE if a group that contains an alternation
+// (at any depth) is followed by a "*", "+" or "{" quantifier.  The pattern
+// must already be valid.
+func hasRepeatedAlternation(pattern string) bool {
+
+	groups := make([]bool, 0) // For each open group, TRUE if it contains a "|"
+	inClass := false
+
+	for index := 0; index < len(pattern); index++ {
+
+		switch character := pattern[index]; {
+
+		case strings.HasPrefix(pattern[index:], `\Q`):
+
+			// Skip quoted text, up to the closing \E (if any)
+			if end := strings.Index(pattern[index:], `\E`); end >= 0 {
+				index += end + 1
+			} else {
+				index = len(pattern)
+			}
+
+		case character == '\\':
+			index++ // Skip the escaped character
+
+		case inClass:
+			inClass = (character != ']')
+
+		case character == '[':
+			inClass = true
+
+			// A "]" at the start of a class is a literal
+			if strings.HasPrefix(pattern[index+1:], "^]") {
+				index += 2
+			} else if strings.HasPrefix(pattern[index+1:], "]") {
+				index++
+			}
+
+		case character == '(':
+			groups = append(groups, false)
+
+		case character == '|':
+			if len(groups) > 0 {
+				groups[len(groups)-1] = true
+			}
+
+		case character == ')':
+
+			if len(groups) == 0 {
+				continue
+			}
+
+			hasAlternation := groups[len(groups)-1]
+			groups = groups[:len(groups)-1]
+
+			if !hasAlternation {
+				continue
+			}
+
+			if (index+1 < len(pattern)) && strings.ContainsRune("*+{", rune(pattern[index+1])) {
+				return true
+			}
+
+			// An enclosing group also contains this alternation
+			if len(groups) > 0 {
+				groups[len(groups)-1] = true
+			}
+		}
+	}
+
+	return false
+}
+
+// stripExtended removes the whitespace and "#" comments that the server
+// ignores when a pattern uses the "x" (extended) option.  Escaped characters,
+// character classes and \Q...\E quotes are kept as written, as they are in
+// PCRE.
+func stripExtended(pattern string) string {
+
+	var result strings.Builder
+	inClass := false
+
+	for index := 0; index < len(pattern); index++ {
+
+		switch character := pattern[index]; {
+
+		case strings.HasPrefix(pattern[index:], `\Q`):
+
+			// Keep quoted text, up to the closing \E (if any)
+			end := len(pattern)
+
+			if found := strings.Index(pattern[index:], `\E`); found >= 0 {
+				end = index + found + 2
+			}
+
+			result.WriteString(pattern[index:end])
+			index = end - 1
+
+		case character == '\\':
+
+			// Keep the escaped character
+			result.WriteByte(character)
+
+			if index+1 < len(pattern) {
+				index++
+				result.WriteByte(pattern[index])
+			}
+
+		case inClass:
+			inClass = (character != ']')
+			result.WriteByte(character)
+
+		case character == '[':
+			inClass = true
+			result.WriteByte(character)
+
+			// A "]" at the start of a class is a literal
+			if strings.HasPrefix(pattern[index+1:], "^]") {
+				result.WriteString("^]")
+				index += 2
+			} else if strings.HasPrefix(pattern[index+1:], "]") {
+				result.WriteByte(']')
+				index++
+			}
+
+		case strings.IndexByte(" \t\n\r\f\v", character) >= 0:
+			// Skip whitespace
+
+		case character == '#':
+
+			// Skip the comment, up to the end of the line
+			if end := strings.IndexByte(pattern[index:], '\n'); end >= 0 {
+				index += end
+			} else {
+				index = len(pattern)
+			}
+
+		default:
+			result.WriteByte(character)
+		}
+	}
+
+	return result.String()
+}
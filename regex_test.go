@@ -0,0 +1,121 @@
+package mongodb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benpate/derp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// withRegexMode sets the RegexMode for a single test
+func withRegexMode(t *testing.T, mode RegexMode) {
+	previous := RegexMode(regexMode.Load())
+	SetRegexMode(mode)
+	t.Cleanup(func() { SetRegexMode(previous) })
+}
+
+func TestCheckRegex_Safe(t *testing.T) {
+
+	safe := []string{
+		"", "John", "^J(oh)?n$", `^\d{3}-\d{4}$`, "[a-z]+@example\\.com",
+		"(?i)connor", "a|b|c", "(cat|dog)s?", "^(ab)?c+$", `\bword\b`,
+		`\(a|b\)+`, "[(a|b)]+", `\Q(a|b)+\E`, `\Q)\E`, "[]|]+",
+		".*foo.*", `^\d{3}-\d{4}-\d{4}-\d{4}$`, `^[a-z]+@[a-z]+\.com$`,
+		strings.Repeat("a", MaxRegexLength),
+	}
+
+	for _, pattern := range safe {
+		assert.NoError(t, checkRegex(pattern), "pattern=%q", pattern)
+	}
+}
+
+func TestCheckRegex_Unsafe(t *testing.T) {
+
+	unsafe := []string{
+		// Nested repetition
+		"(a+)+", "(a*)*", "(a+)*$", "(.*a){10}", "((ab)+c)+", "(a?)+",
+
+		// Repetition of an alternation
+		"(a|ab)*", "(a|a)+$", "(cat|dog)+", "((a|a)b)+", "(?:a|a){2,}", `\Q(\E(a|a)+`,
+
+		// Too many adjacent, overlapping repetitions
+		strings.Repeat(".*", 16) + "!", strings.Repeat(`\d*`, 16) + "x",
+		strings.Repeat("(a|a)?", 16) + "aaaa", ".*.*.*", "a+b*c?", "a{1,3}b{2,}c*",
+
+		// Backreferences and lookarounds are not supported
+		`(a)\1`, "a(?=b)", "a(?!b)", "(?<=a)b",
+
+		// Invalid syntax
+		"(abc", "[z-a]",
+
+		// Too long
+		strings.Repeat("a", MaxRegexLength+1),
+	}
+
+	for _, pattern := range unsafe {
+		assert.True(t, derp.IsBadRequest(checkRegex(pattern)), "pattern=%q", pattern)
+	}
+}
+
+func TestRegexMode(t *testing.T) {
+
+	// RegexSafe is the default
+	assert.Equal(t, RegexSafe, RegexMode(regexMode.Load()))
+
+	_, err := regexBSON("(a+)+")
+	assert.True(t, derp.IsBadRequest(err))
+
+	// RegexUnrestricted passes every pattern through
+	withRegexMode(t, RegexUnrestricted)
+	result, err := regexBSON("(a+)+")
+	require.NoError(t, err)
+	assert.Equal(t, primitive.Regex{Pattern: "(a+)+"}, result)
+
+	// ...but still checks the value and options
+	_, err = regexBSON(primitive.Regex{Pattern: "a", Options: "g"})
+	assert.True(t, derp.IsBadRequest(err))
+
+	// RegexDisabled rejects every pattern
+	SetRegexMode(RegexDisabled)
+	_, err = regexBSON("John")
+	assert.True(t, derp.IsBadRequest(err))
+}
+
+// In extended mode, the server ignores whitespace and comments, so they
+// cannot hide an unsafe pattern.
+func TestRegexMode_Extended(t *testing.T) {
+
+	for _, pattern := range []string{
+		"(a + ) +",
+		"(a|a)#\n*",
+		"( a | b ) {2,}",
+	} {
+		_, err := regexBSON(primitive.Regex{Pattern: pattern, Options: "x"})
+		assert.True(t, derp.IsBadRequest(err), "pattern=%q", pattern)
+
+		_, err = TranslateExpression(Regex("name", primitive.Regex{Pattern: pattern, Options: "x"}))
+		assert.True(t, derp.IsBadRequest(err), "pattern=%q", pattern)
+	}
+
+	// Safe patterns (and escaped or quoted whitespace) are still accepted
+	for _, pattern := range []string{
+		"^ jo hn $ # first name\n",
+		`a\ +`,
+		"[ ]+",
+		`\Q(a + ) +\E`,
+	} {
+		_, err := regexBSON(primitive.Regex{Pattern: pattern, Options: "ix"})
+		assert.NoError(t, err, "pattern=%q", pattern)
+	}
+}
+
+func TestStripExtended(t *testing.T) {
+	assert.Equal(t, "(a+)+", stripExtended("(a + ) +"))
+	assert.Equal(t, "(a|a)*", stripExtended("(a|a)#\n*"))
+	assert.Equal(t, "ab", stripExtended("a # comment\n b # trailing"))
+	assert.Equal(t, `a\ b[ c]`, stripExtended(`a\ b [ c]`))
+	assert.Equal(t, `\Q a \E`, stripExtended(`\Q a \E`))
+}
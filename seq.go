@@ -0,0 +1,111 @@
+package mongodb
+
+import (
+	"iter"
+
+	"github.com/benpate/data"
+	"github.com/benpate/data/option"
+	"github.com/benpate/exp"
+)
+
+// decoder is implemented by iterators that can report why a document could not
+// be decoded, instead of simply returning FALSE.
+type decoder interface {
+	decodeNext(output any) (bool, error)
+}
+
+// Seq returns a range-over-func adapter that decodes every remaining record in
+// the iterator into a new T.  The iterator is closed when the loop ends,
+// including when the caller breaks out early.  Iteration stops at the first
+// error; use Seq2 to receive errors.
+func Seq[T any](iterator data.Iterator) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for value, err := range Seq2[T](iterator) {
+			if err != nil {
+				return
+			}
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Seq2 returns a range-over-func adapter that decodes every remaining record in
+// the iterator into a new T, yielding each value alongside any error that
+// occurred while decoding it.  A document that fails to decode yields its
+// error and iteration continues with the next document; a cursor error is
+// yielded once, at the end.  The iterator is closed when the loop ends,
+// including when the caller breaks out early.
+func Seq2[T any](iterator data.Iterator) iter.Seq2[T, error] {
+	return seq2(iterator, func() (T, bool, error) {
+		var result T
+		ok, err := decodeNext(iterator, &result)
+		return result, ok, err
+	})
+}
+
+// Range queries the collection and returns a range-over-func adapter over the
+// results, decoding each record into a new T.  The query is not run until the
+// loop begins.  Errors from the query itself are yielded as the first (and
+// only) value.
+func Range[T any](collection Collection, criteria exp.Expression, options ...option.Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+
+		iterator, err := collection.Iterator(criteria, options...)
+
+		if err != nil {
+			var empty T
+			yield(empty, err)
+			return
+		}
+
+		for value, err := range Seq2[T](iterator) {
+			if !yield(value, err) {
+				return
+			}
+		}
+	}
+}
+
+// seq2 implements Seq2 for any iterator.  next decodes the next record from the
+// iterator, returning FALSE once it is exhausted.
+func seq2[T any](iterator data.Iterator, next func() (T, bool, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+
+		if iterator == nil {
+			return
+		}
+
+		defer iterator.Close()
+
+		for {
+			value, ok, err := next()
+
+			if !ok {
+				break
+			}
+
+			if !yield(value, err) {
+				return
+			}
+		}
+
+		// Report cursor errors (such as a cancelled context) once the loop is done
+		if err := iterator.Error(); err != nil {
+			var empty T
+			yield(empty, err)
+		}
+	}
+}
+
+// decodeNext populates output with the next record from the iterator,
+// reporting decode errors when the iterator supports it.
+func decodeNext(iterator data.Iterator, output any) (bool, error) {
+
+	if decoder, ok := iterator.(decoder); ok {
+		return decoder.decodeNext(output)
+	}
+
+	return iterator.Next(output), nil
+}
@@ -0,0 +1,178 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIterator is an in-memory data.Iterator that records whether it was closed.
+type fakeIterator struct {
+	names  []string
+	err    error
+	closed bool
+}
+
+func (iterator *fakeIterator) Next(output any) bool {
+	if len(iterator.names) == 0 {
+		return false
+	}
+	output.(*testPerson).Name = iterator.names[0]
+	iterator.names = iterator.names[1:]
+	return true
+}
+
+func (iterator *fakeIterator) Error() error { return iterator.err }
+func (iterator *fakeIterator) Count() int   { return len(iterator.names) }
+func (iterator *fakeIterator) Close() error { iterator.closed = true; return nil }
+
+/******************************************
+ * Seq / Seq2 (no database)
+ ******************************************/
+
+func TestSeq2_ClosesWhenExhausted(t *testing.T) {
+
+	iterator := &fakeIterator{names: []string{"A", "B", "C"}}
+
+	names := make([]string, 0)
+	for person, err := range Seq2[testPerson](iterator) {
+		require.NoError(t, err)
+		names = append(names, person.Name)
+	}
+
+	assert.Equal(t, []string{"A", "B", "C"}, names)
+	assert.True(t, iterator.closed)
+}
+
+func TestSeq2_ClosesOnBreak(t *testing.T) {
+
+	iterator := &fakeIterator{names: []string{"A", "B", "C"}}
+
+	for person := range Seq[testPerson](iterator) {
+		assert.Equal(t, "A", person.Name)
+		break
+	}
+
+	assert.True(t, iterator.closed)
+	assert.Equal(t, 2, iterator.Count()) // the remaining records were never read
+}
+
+// Cursor errors are yielded once, after the last record.
+func TestSeq2_CursorError(t *testing.T) {
+
+	iterator := &fakeIterator{names: []string{"A"}, err: errors.New("cursor failed")}
+
+	errorCount := 0
+	for _, err := range Seq2[testPerson](iterator) {
+		if err != nil {
+			errorCount++
+		}
+	}
+
+	assert.Equal(t, 1, errorCount)
+	assert.True(t, iterator.closed)
+}
+
+// Seq stops silently at the first error.
+func TestSeq_StopsOnError(t *testing.T) {
+
+	iterator := &fakeIterator{names: []string{"A", "B"}, err: errors.New("cursor failed")}
+
+	names := make([]string, 0)
+	for person := range Seq[testPerson](iterator) {
+		names = append(names, person.Name)
+	}
+
+	assert.Equal(t, []string{"A", "B"}, names)
+}
+
+// Cursor-less and nil iterators yield nothing.
+func TestSeq2_Empty(t *testing.T) {
+
+	assert.NotPanics(t, func() {
+		for range Seq2[testPerson](Iterator{}) {
+			t.Fatal("a cursor-less iterator must not yield")
+		}
+		for range Seq2[testPerson](nil) {
+			t.Fatal("a nil iterator must not yield")
+		}
+	})
+}
+
+func TestTypedIterator_Seq(t *testing.T) {
+
+	iterator := &fakeIterator{names: []string{"A", "B"}}
+	typed := NewTypedIterator(iterator, func() *testPerson { return &testPerson{} })
+
+	result := make([]*testPerson, 0)
+	for person := range typed.Seq() {
+		result = append(result, person)
+	}
+
+	require.Len(t, result, 2)
+	assert.NotSame(t, result[0], result[1])
+	assert.Equal(t, "A", result[0].Name)
+	assert.Equal(t, "B", result[1].Name)
+	assert.True(t, iterator.closed)
+}
+
+/******************************************
+ * Range (live database)
+ ******************************************/
+
+func TestRange(t *testing.T) {
+
+	collection := getTestCollection(t)
+	require.NoError(t, collection.Save(newTestPerson("John Connor", 20), "seed"))
+	require.NoError(t, collection.Save(newTestPerson("Sarah Connor", 45), "seed"))
+
+	names := make([]string, 0)
+	for person, err := range Range[testPerson](collection, exp.All()) {
+		require.NoError(t, err)
+		names = append(names, person.Name)
+	}
+
+	assert.ElementsMatch(t, []string{"John Connor", "Sarah Connor"}, names)
+}
+
+// Documents that cannot be decoded yield an error, and iteration continues.
+func TestRange_DecodeError(t *testing.T) {
+
+	collection := getTestCollection(t)
+	require.NoError(t, collection.Save(newTestPerson("John Connor", 20), "seed"))
+	_, err := collection.Mongo().InsertOne(collection.Context(), map[string]any{"name": "Broken", "age": "not a number"})
+	require.NoError(t, err)
+
+	names := make([]string, 0)
+	errorCount := 0
+	for person, err := range Range[testPerson](collection, exp.All()) {
+		if err != nil {
+			errorCount++
+			continue
+		}
+		names = append(names, person.Name)
+	}
+
+	assert.Equal(t, []string{"John Connor"}, names)
+	assert.Equal(t, 1, errorCount)
+}
+
+func TestTypedCollection_Range(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+	require.NoError(t, people.Save(newTestPerson("John Connor", 20), "seed"))
+	require.NoError(t, people.Save(newTestPerson("Sarah Connor", 45), "seed"))
+
+	count := 0
+	for person, err := range people.Range(exp.All()) {
+		require.NoError(t, err)
+		assert.NotEmpty(t, person.Name)
+		count++
+		break
+	}
+
+	assert.Equal(t, 1, count)
+}
@@ -22,11 +22,13 @@ import (
 type Server struct {
 	client   *mongo.Client
 	database *mongo.Database
+	settings settings
 }
 
 // New returns a fully populated mongodb.Server.  It requires that you provide the URI for the mongodb
-// cluster, along with the name of the database to be used for all transactions.
-func New(uri string, database string, opt *options.ClientOptions) (Server, error) {
+// cluster, along with the name of the database to be used for all transactions.  Optional settings
+// are passed down to every Session (and Collection) that the Server opens.
+func New(uri string, database string, opt *options.ClientOptions, list ...Setting) (Server, error) {
 
 	const location = "data-mongo.Server.New"
 
@@ -47,19 +49,28 @@ func New(uri string, database string, opt *options.ClientOptions) (Server, error
 	result := Server{
 		client:   client,
 		database: client.Database(database),
+		settings: settings{}.apply(list...),
 	}
 
 	return result, nil
 }
 
 // NewServer wraps an existing *mongo.Database in a data.Server.
-func NewServer(database *mongo.Database) Server {
+func NewServer(database *mongo.Database, list ...Setting) Server {
 	return Server{
 		client:   database.Client(),
 		database: database,
+		settings: settings{}.apply(list...),
 	}
 }
 
+// With returns a copy of this Server with additional settings applied.  The
+// settings are passed down to every Session that the Server opens.
+func (server Server) With(list ...Setting) Server {
+	server.settings = server.settings.apply(list...)
+	return server
+}
+
 // Client returns the underlying mongodb client for libraries that need to bypass this abstraction.
 func (server Server) Client() *mongo.Client {
 	return server.client
@@ -75,6 +86,7 @@ func (server Server) Session(ctx context.Context) (data.Session, error) {
 	return Session{
 		database: server.database,
 		context:  ctx,
+		settings: server.settings,
 	}, nil
 }
 
@@ -109,6 +121,7 @@ func (server Server) WithTransaction(ctx context.Context, fn data.TransactionCal
 		session := Session{
 			database: server.database,
 			context:  ctx,
+			settings: server.settings,
 		}
 
 		// Execute the Transaction
@@ -2,9 +2,11 @@ package mongodb
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/benpate/data"
+	"github.com/benpate/derp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -154,3 +156,30 @@ func TestServer_WithTransaction_Rollback(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), count)
 }
+
+// The revision check also applies to Collections opened inside a transaction.
+func TestServer_WithTransaction_RevisionConflict(t *testing.T) {
+
+	server := getTestServer(t).With(WithRevisionCheck())
+
+	person := newTestPerson("Sarah Connor", 45)
+	session, err := server.Session(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, session.Collection("testPeople").Save(person, "seed"))
+
+	// Another process saves the document, making the in-memory copy stale.
+	stale := *person
+	require.NoError(t, session.Collection("testPeople").Save(person, "elsewhere"))
+
+	_, err = server.WithTransaction(context.Background(), func(session data.Session) (any, error) {
+		return nil, session.Collection("testPeople").Save(&stale, "in transaction")
+	})
+
+	// A standalone (non-replica-set) server cannot run transactions; skip there.
+	if err != nil && derp.ErrorCode(err) != http.StatusConflict {
+		t.Skipf("MongoDB transaction not supported in this configuration: %v", err)
+	}
+
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, derp.ErrorCode(err))
+}
@@ -12,22 +12,32 @@ import (
 type Session struct {
 	database *mongo.Database
 	context  context.Context
+	settings settings
 }
 
 // NewSession generates a new Session object from a mongo.Database
-func NewSession(database *mongo.Database) Session {
+func NewSession(database *mongo.Database, list ...Setting) Session {
 	return Session{
 		database: database,
 		context:  context.Background(),
+		settings: settings{}.apply(list...),
 	}
 }
 
+// With returns a copy of this Session with additional settings applied.  The
+// settings are passed down to every Collection that the Session opens.
+func (s Session) With(list ...Setting) Session {
+	s.settings = s.settings.apply(list...)
+	return s
+}
+
 // Collection returns a reference to an individual database collection.
 func (s Session) Collection(collection string) data.Collection {
 
 	return Collection{
 		collection: s.database.Collection(collection),
 		context:    s.context,
+		settings:   s.settings,
 	}
 }
 
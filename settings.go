@@ -0,0 +1,113 @@
+package mongodb
+
+import (
+	"time"
+)
+
+// settings holds the optional behaviors that a Server passes down to each of
+// its Sessions, and that a Session passes down to each of its Collections.
+type settings struct {
+	revisionCheck  bool
+	excludeDeleted bool
+	idCodec        IDCodec
+	pageTokenKey   []byte
+	maxTime        time.Duration
+	collation      *Collation
+}
+
+// Setting is a functional option that configures the optional behaviors of a
+// Server, Session, or Collection.
+type Setting func(*settings)
+
+// WithRevisionCheck enables optimistic concurrency control on Save.  Updates
+// only succeed if the stored document still has the revision that the object
+// was loaded with; otherwise Save returns a 409 Conflict error.
+func WithRevisionCheck() Setting {
+	return func(s *settings) {
+		s.revisionCheck = true
+	}
+}
+
+// WithoutRevisionCheck disables optimistic concurrency control on Save, so
+// that the last writer wins.  This is the default behavior.
+func WithoutRevisionCheck() Setting {
+	return func(s *settings) {
+		s.revisionCheck = false
+	}
+}
+
+// WithoutDeleted excludes virtually-deleted documents from Count, Query,
+// Iterator, Load, and Update, so that callers do not need to add a deleteDate
+// filter to every query.  Individual queries can opt back in with the
+// IncludeDeleted option.
+func WithoutDeleted() Setting {
+	return func(s *settings) {
+		s.excludeDeleted = true
+	}
+}
+
+// WithDeleted includes virtually-deleted documents in every query.  This is
+// the default behavior.
+func WithDeleted() Setting {
+	return func(s *settings) {
+		s.excludeDeleted = false
+	}
+}
+
+// WithIDCodec sets the codec that converts object IDs into stored `_id`
+// values.  ObjectIDCodec is used when no codec is set, or when codec is nil.
+func WithIDCodec(codec IDCodec) Setting {
+	return func(s *settings) {
+		s.idCodec = codec
+	}
+}
+
+// WithPageTokenKey sets the secret key that signs the page tokens returned by
+// Collection.PageAfter, so that callers cannot forge or alter them.  PageAfter
+// refuses to run until a key is set.
+func WithPageTokenKey(key []byte) Setting {
+	key = append([]byte(nil), key...)
+	return func(s *settings) {
+		s.pageTokenKey = key
+	}
+}
+
+// WithMaxTime sets the default time budget for each query, which limits how
+// long the server may spend running it (maxTimeMS).  Queries that exceed it
+// fail with a 504 Gateway Timeout error.  Individual queries can override it
+// with the MaxTime option.  A zero duration (the default) means no limit.
+func WithMaxTime(duration time.Duration) Setting {
+	return func(s *settings) {
+		s.maxTime = duration
+	}
+}
+
+// WithCollation sets the default collation for each query, which controls how
+// strings are compared and sorted (for example, using German or Turkish rules).
+// Individual queries can override it with the Collate option, and a
+// CaseSensitive option overrides its strength.  Without a collation, strings
+// are compared by their binary values.
+func WithCollation(collation Collation) Setting {
+	return func(s *settings) {
+		s.collation = &collation
+	}
+}
+
+// apply returns a copy of these settings with each Setting applied in order.
+func (s settings) apply(list ...Setting) settings {
+	for _, setting := range list {
+		setting(&s)
+	}
+	return s
+}
+
+// decodeID converts an object ID into its stored `_id` value, using the
+// configured IDCodec or ObjectIDCodec by default.
+func (s settings) decodeID(id string) (any, error) {
+
+	if s.idCodec == nil {
+		return ObjectIDCodec(id)
+	}
+
+	return s.idCodec(id)
+}
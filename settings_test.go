@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/******************************************
+ * settings.apply()
+ ******************************************/
+
+// The zero value is the default behavior.
+func TestSettings_Default(t *testing.T) {
+	assert.False(t, settings{}.revisionCheck)
+	assert.False(t, settings{}.excludeDeleted)
+}
+
+// Settings are applied in order, so later settings win.
+func TestSettings_Apply(t *testing.T) {
+
+	result := settings{}.apply(WithRevisionCheck())
+	assert.True(t, result.revisionCheck)
+
+	result = settings{}.apply(WithRevisionCheck(), WithoutRevisionCheck())
+	assert.False(t, result.revisionCheck)
+
+	result = settings{}.apply(WithoutDeleted())
+	assert.True(t, result.excludeDeleted)
+
+	result = settings{}.apply(WithoutDeleted(), WithDeleted())
+	assert.False(t, result.excludeDeleted)
+}
+
+// WithPageTokenKey keeps its own copy of the key.
+func TestSettings_PageTokenKey(t *testing.T) {
+
+	key := []byte("secret")
+	result := settings{}.apply(WithPageTokenKey(key))
+	key[0] = 'X'
+
+	assert.Equal(t, []byte("secret"), result.pageTokenKey)
+}
+
+func TestSettings_MaxTime(t *testing.T) {
+	assert.Zero(t, settings{}.maxTime)
+	assert.Equal(t, time.Second, settings{}.apply(WithMaxTime(time.Second)).maxTime)
+}
+
+func TestSettings_Collation(t *testing.T) {
+	assert.Nil(t, settings{}.collation)
+
+	result := settings{}.apply(WithCollation(Collation{Locale: "de"}))
+	require.NotNil(t, result.collation)
+	assert.Equal(t, "de", result.collation.Locale)
+}
+
+// Applying settings returns a copy, leaving the original unchanged.
+func TestSettings_ApplyCopies(t *testing.T) {
+
+	original := settings{}
+	_ = original.apply(WithRevisionCheck())
+
+	assert.False(t, original.revisionCheck)
+}
+
+/******************************************
+ * Propagation
+ ******************************************/
+
+// Settings flow from the Server, through each Session, into each Collection.
+func TestSettings_Propagation(t *testing.T) {
+
+	server := getTestServer(t).With(WithRevisionCheck())
+
+	session, err := server.Session(context.Background())
+	require.NoError(t, err)
+	assert.True(t, session.(Session).settings.revisionCheck)
+
+	collection := session.Collection("testPeople").(Collection)
+	assert.True(t, collection.settings.revisionCheck)
+
+	// A Collection can override the settings it inherited.
+	assert.False(t, collection.With(WithoutRevisionCheck()).settings.revisionCheck)
+	assert.True(t, collection.settings.revisionCheck)
+}
+
+func TestSettings_Constructors(t *testing.T) {
+
+	server := getTestServer(t)
+
+	assert.True(t, NewServer(server.Database(), WithRevisionCheck()).settings.revisionCheck)
+	assert.True(t, NewSession(server.Database(), WithRevisionCheck()).settings.revisionCheck)
+	assert.True(t, NewSession(server.Database()).With(WithRevisionCheck()).settings.revisionCheck)
+	assert.True(t, NewCollection(server.Database().Collection("raw"), WithRevisionCheck()).settings.revisionCheck)
+}
@@ -2,11 +2,13 @@ package mongodb
 
 import (
 	"context"
+	"encoding/hex"
 	"testing"
 	"time"
 
 	"github.com/benpate/data"
 	"github.com/benpate/data/journal"
+	"github.com/benpate/exp"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -118,3 +120,103 @@ func (b *badIDObject) ID() string {
 func (b *badIDObject) markOld() {
 	b.CreateDate = time.Now().UnixMilli()
 }
+
+// testRecord is a data.Object whose primary key is stored as a string (such as
+// a slug or remote URL), used to exercise StringIDCodec.
+type testRecord struct {
+	RecordID        string `bson:"_id"`
+	Value           string `bson:"value"`
+	journal.Journal `bson:"journal"`
+}
+
+var _ data.Object = (*testRecord)(nil)
+
+// ID implements the data.Object interface.
+func (record *testRecord) ID() string {
+	return record.RecordID
+}
+
+// testUUIDRecord is a data.Object whose primary key is stored as a BSON UUID,
+// used to exercise UUIDCodec.
+type testUUIDRecord struct {
+	RecordID        primitive.Binary `bson:"_id"`
+	Value           string           `bson:"value"`
+	journal.Journal `bson:"journal"`
+}
+
+var _ data.Object = (*testUUIDRecord)(nil)
+
+// newTestUUIDRecord builds a brand-new (unsaved) testUUIDRecord from a UUID string.
+func newTestUUIDRecord(t *testing.T, uuid string, value string) *testUUIDRecord {
+	t.Helper()
+
+	id, err := UUIDCodec(uuid)
+	require.NoError(t, err)
+
+	return &testUUIDRecord{
+		RecordID: id.(primitive.Binary),
+		Value:    value,
+	}
+}
+
+// ID implements the data.Object interface, formatting the UUID canonically.
+func (record *testUUIDRecord) ID() string {
+	value := hex.EncodeToString(record.RecordID.Data)
+	return value[0:8] + "-" + value[8:12] + "-" + value[12:16] + "-" + value[16:20] + "-" + value[20:]
+}
+
+// testMessage is a data.Object with array fields, used to exercise the array
+// operators and projections.
+type testMessage struct {
+	MessageID       primitive.ObjectID `bson:"_id"`
+	Subject         string             `bson:"subject"`
+	Tags            []string           `bson:"tags"`
+	Recipients      []testRecipient    `bson:"recipients"`
+	journal.Journal `bson:"journal"`
+}
+
+// testRecipient is a sub-document embedded in a testMessage.
+type testRecipient struct {
+	Name   string `bson:"name"`
+	Role   string `bson:"role"`
+	Status string `bson:"status"`
+}
+
+var _ data.Object = (*testMessage)(nil)
+
+// newTestMessage builds a brand-new (unsaved) testMessage with a unique ID.
+func newTestMessage(subject string, tags ...string) *testMessage {
+	return &testMessage{
+		MessageID:  primitive.NewObjectID(),
+		Subject:    subject,
+		Tags:       tags,
+		Recipients: []testRecipient{},
+	}
+}
+
+// ID implements the data.Object interface.
+func (message *testMessage) ID() string {
+	return message.MessageID.Hex()
+}
+
+// getTestMessages returns a Collection of messages backed by a fresh, empty test database.
+func getTestMessages(t *testing.T, messages ...*testMessage) Collection {
+	t.Helper()
+
+	collection := getTestCollection(t)
+
+	for _, message := range messages {
+		require.NoError(t, collection.Save(message, "seed"))
+	}
+
+	return collection
+}
+
+// loadTestMessage reloads a message from the database.
+func loadTestMessage(t *testing.T, collection Collection, id primitive.ObjectID) testMessage {
+	t.Helper()
+
+	result := testMessage{}
+	require.NoError(t, collection.Load(exp.Equal("_id", id), &result))
+	return result
+}
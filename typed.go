@@ -0,0 +1,237 @@
+package mongodb
+
+import (
+	"iter"
+
+	"github.com/benpate/data"
+	"github.com/benpate/data/option"
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+)
+
+// TypedCollection wraps a Collection so that every read returns a concrete
+// type T instead of decoding into an untyped target.  T is usually a pointer
+// to a struct, such as *Person.
+type TypedCollection[T data.Object] struct {
+	collection Collection
+	newObject  func() T
+}
+
+// NewTypedCollection returns a TypedCollection that reads and writes T values
+// through the provided Collection.  newObject must return a new, empty T that
+// is ready to be decoded into.
+func NewTypedCollection[T data.Object](collection Collection, newObject func() T) TypedCollection[T] {
+	return TypedCollection[T]{
+		collection: collection,
+		newObject:  newObject,
+	}
+}
+
+// Collection returns the untyped Collection that this TypedCollection wraps
+func (c TypedCollection[T]) Collection() Collection {
+	return c.collection
+}
+
+// Count returns the number of records that match the provided criteria
+func (c TypedCollection[T]) Count(criteria exp.Expression, options ...option.Option) (int64, error) {
+	return c.collection.Count(criteria, options...)
+}
+
+// Load retrieves a single object from the database
+func (c TypedCollection[T]) Load(criteria exp.Expression, options ...option.Option) (T, error) {
+
+	const location = "data-mongo.TypedCollection.Load"
+
+	result := c.newObject()
+
+	if err := c.collection.Load(criteria, result, options...); err != nil {
+		return result, derp.Wrap(err, location, "Loading object")
+	}
+
+	return result, nil
+}
+
+// Query retrieves all objects that match the provided criteria
+func (c TypedCollection[T]) Query(criteria exp.Expression, options ...option.Option) ([]T, error) {
+
+	const location = "data-mongo.TypedCollection.Query"
+
+	result := make([]T, 0)
+
+	if err := c.collection.Query(&result, criteria, options...); err != nil {
+		return nil, derp.Wrap(err, location, "Listing objects")
+	}
+
+	return result, nil
+}
+
+// Page retrieves a single page of objects from the database, along with the
+// page metadata.  See Collection.Page for details.
+func (c TypedCollection[T]) Page(criteria exp.Expression, page int64, pageSize int64, options ...option.Option) ([]T, PageInfo, error) {
+
+	const location = "data-mongo.TypedCollection.Page"
+
+	result := make([]T, 0)
+	info, err := c.collection.Page(&result, criteria, page, pageSize, options...)
+
+	if err != nil {
+		return nil, info, derp.Wrap(err, location, "Loading page")
+	}
+
+	return result, info, nil
+}
+
+// PageAfter retrieves the page of objects that follows pageToken, along with
+// the token for the page after that.  See Collection.PageAfter for details.
+func (c TypedCollection[T]) PageAfter(criteria exp.Expression, pageToken string, pageSize int64, options ...option.Option) ([]T, string, error) {
+
+	const location = "data-mongo.TypedCollection.PageAfter"
+
+	result := make([]T, 0)
+	nextToken, err := c.collection.PageAfter(&result, criteria, pageToken, pageSize, options...)
+
+	if err != nil {
+		return nil, "", derp.Wrap(err, location, "Loading page")
+	}
+
+	return result, nextToken, nil
+}
+
+// Iterator retrieves a group of objects from the database as a TypedIterator
+func (c TypedCollection[T]) Iterator(criteria exp.Expression, options ...option.Option) (TypedIterator[T], error) {
+
+	const location = "data-mongo.TypedCollection.Iterator"
+
+	iterator, err := c.collection.Iterator(criteria, options...)
+	result := NewTypedIterator(iterator, c.newObject)
+
+	if err != nil {
+		return result, derp.Wrap(err, location, "Listing objects")
+	}
+
+	return result, nil
+}
+
+// Range queries the collection and returns a range-over-func adapter over the
+// results.  See the package-level Range function for details.
+func (c TypedCollection[T]) Range(criteria exp.Expression, options ...option.Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+
+		iterator, err := c.Iterator(criteria, options...)
+
+		if err != nil {
+			yield(c.newObject(), err)
+			return
+		}
+
+		for value, err := range iterator.Seq2() {
+			if !yield(value, err) {
+				return
+			}
+		}
+	}
+}
+
+// Save inserts/updates a single object in the database
+func (c TypedCollection[T]) Save(object T, note string) error {
+	return c.collection.Save(object, note)
+}
+
+// Delete virtually deletes a single object from the database
+func (c TypedCollection[T]) Delete(object T, note string) error {
+	return c.collection.Delete(object, note)
+}
+
+// TypedIterator wraps a data.Iterator so that each call to Next returns a
+// new T value.
+type TypedIterator[T data.Object] struct {
+	iterator  data.Iterator
+	newObject func() T
+}
+
+// NewTypedIterator returns a TypedIterator that decodes each record from the
+// provided data.Iterator into a new T.
+func NewTypedIterator[T data.Object](iterator data.Iterator, newObject func() T) TypedIterator[T] {
+	return TypedIterator[T]{
+		iterator:  iterator,
+		newObject: newObject,
+	}
+}
+
+// Count returns the number of records remaining in the wrapped iterator's
+// current batch.  Use Total for the number of records that matched the query.
+func (iterator TypedIterator[T]) Count() int {
+	if iterator.iterator == nil {
+		return 0
+	}
+	return iterator.iterator.Count()
+}
+
+// Total returns the number of records that matched the query.  The second
+// value is FALSE unless the wrapped Iterator was created with the CountTotal
+// option.
+func (iterator TypedIterator[T]) Total() (int64, bool) {
+	if totaler, ok := iterator.iterator.(interface{ Total() (int64, bool) }); ok {
+		return totaler.Total()
+	}
+	return 0, false
+}
+
+// Next returns the next value from the wrapped iterator, or FALSE if the
+// iterator is exhausted.
+func (iterator TypedIterator[T]) Next() (T, bool) {
+
+	result := iterator.newObject()
+
+	if iterator.iterator == nil {
+		return result, false
+	}
+
+	if !iterator.iterator.Next(result) {
+		return result, false
+	}
+
+	return result, true
+}
+
+// Close closes the wrapped iterator
+func (iterator TypedIterator[T]) Close() error {
+	if iterator.iterator == nil {
+		return nil
+	}
+	return iterator.iterator.Close()
+}
+
+// Error returns any error encountered while iterating the wrapped iterator
+func (iterator TypedIterator[T]) Error() error {
+	if iterator.iterator == nil {
+		return nil
+	}
+	return iterator.iterator.Error()
+}
+
+// Seq returns a range-over-func adapter over the remaining values in this
+// iterator.  See the package-level Seq function for details.
+func (iterator TypedIterator[T]) Seq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for value, err := range iterator.Seq2() {
+			if err != nil {
+				return
+			}
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Seq2 returns a range-over-func adapter over the remaining values in this
+// iterator, along with any decode errors.  See the package-level Seq2 function
+// for details.
+func (iterator TypedIterator[T]) Seq2() iter.Seq2[T, error] {
+	return seq2(iterator.iterator, func() (T, bool, error) {
+		result := iterator.newObject()
+		ok, err := decodeNext(iterator.iterator, result)
+		return result, ok, err
+	})
+}
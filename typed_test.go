@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/benpate/derp"
+	"github.com/benpate/exp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPersonCollection wraps a Collection as a TypedCollection of *testPerson.
+func newTestPersonCollection(collection Collection) TypedCollection[*testPerson] {
+	return NewTypedCollection(collection, func() *testPerson { return &testPerson{} })
+}
+
+/******************************************
+ * TypedIterator (no database)
+ ******************************************/
+
+// A TypedIterator over a cursor-less iterator (or no iterator at all) is empty.
+func TestTypedIterator_Empty(t *testing.T) {
+
+	newObject := func() *testPerson { return &testPerson{} }
+
+	for _, iterator := range []TypedIterator[*testPerson]{
+		NewTypedIterator(Iterator{}, newObject),
+		NewTypedIterator(nil, newObject),
+	} {
+		assert.NotPanics(t, func() {
+			person, ok := iterator.Next()
+			assert.False(t, ok)
+			assert.NotNil(t, person)
+			assert.Equal(t, 0, iterator.Count())
+			assert.NoError(t, iterator.Error())
+			assert.NoError(t, iterator.Close())
+		})
+	}
+}
+
+/******************************************
+ * TypedCollection (live database)
+ ******************************************/
+
+func TestTypedCollection_SaveAndLoad(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+
+	person := newTestPerson("John Connor", 20)
+	require.NoError(t, people.Save(person, "seed"))
+
+	loaded, err := people.Load(exp.Equal("_id", person.PersonID))
+	require.NoError(t, err)
+	assert.Equal(t, "John Connor", loaded.Name)
+	assert.Equal(t, 20, loaded.Age)
+}
+
+func TestTypedCollection_Load_NotFound(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+
+	_, err := people.Load(exp.Equal("name", "Nobody"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, derp.ErrorCode(err))
+}
+
+func TestTypedCollection_Query(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+	require.NoError(t, people.Save(newTestPerson("John Connor", 20), "seed"))
+	require.NoError(t, people.Save(newTestPerson("Sarah Connor", 45), "seed"))
+
+	result, err := people.Query(exp.All())
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+
+	names := []string{result[0].Name, result[1].Name}
+	assert.ElementsMatch(t, []string{"John Connor", "Sarah Connor"}, names)
+
+	count, err := people.Count(exp.All())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestTypedCollection_Iterator(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+	require.NoError(t, people.Save(newTestPerson("John Connor", 20), "seed"))
+	require.NoError(t, people.Save(newTestPerson("Sarah Connor", 45), "seed"))
+
+	iterator, err := people.Iterator(exp.All())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = iterator.Close() })
+
+	// Each call to Next must return a distinct object.
+	result := make([]*testPerson, 0)
+	for person, ok := iterator.Next(); ok; person, ok = iterator.Next() {
+		result = append(result, person)
+	}
+
+	require.Len(t, result, 2)
+	assert.NotSame(t, result[0], result[1])
+	assert.ElementsMatch(t, []string{"John Connor", "Sarah Connor"}, []string{result[0].Name, result[1].Name})
+	assert.NoError(t, iterator.Error())
+}
+
+func TestTypedCollection_Delete(t *testing.T) {
+
+	people := newTestPersonCollection(getTestCollection(t))
+
+	person := newTestPerson("John Connor", 20)
+	require.NoError(t, people.Save(person, "seed"))
+	require.NoError(t, people.Delete(person, "cleanup"))
+
+	loaded, err := people.Load(exp.Equal("_id", person.PersonID))
+	require.NoError(t, err)
+	assert.NotZero(t, loaded.DeleteDate)
+}
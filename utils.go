@@ -1,7 +1,47 @@
 package mongodb
 
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
 // pointerTo returns a pointer to a copy of the given value, for APIs (such as
 // the mongodb options builders) that take pointer arguments.
 func pointerTo[T any](value T) *T {
 	return &value
 }
+
+// lookupPath returns the value at a dotted path (such as "stats.views") within
+// a document, or nil if any part of the path is missing.
+func lookupPath(document bson.M, path string) any {
+
+	var current any = document
+
+	for _, key := range strings.Split(path, ".") {
+
+		value, ok := current.(bson.M)
+
+		if !ok {
+			return nil
+		}
+
+		current = value[key]
+	}
+
+	return current
+}
+
+// rawValue returns the bson encoding of a value, so that it can be compared
+// with the values in a bson.Raw document.  Values that cannot be encoded
+// return an empty RawValue, which equals nothing that was stored.
+func rawValue(value any) bson.RawValue {
+
+	valueType, data, err := bson.MarshalValue(value)
+
+	if err != nil {
+		return bson.RawValue{}
+	}
+
+	return bson.RawValue{Type: valueType, Value: data}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func TestPointerTo(t *testing.T) {
@@ -18,3 +19,35 @@ func TestPointerTo(t *testing.T) {
 	require.NotNil(t, stringPointer)
 	assert.Equal(t, "hello", *stringPointer)
 }
+
+func TestLookupPath(t *testing.T) {
+
+	document := bson.M{
+		"views": 10,
+		"stats": bson.M{"likes": 5, "nested": bson.M{"deep": "value"}},
+	}
+
+	assert.Equal(t, 10, lookupPath(document, "views"))
+	assert.Equal(t, 5, lookupPath(document, "stats.likes"))
+	assert.Equal(t, "value", lookupPath(document, "stats.nested.deep"))
+
+	// Missing paths, and paths through non-documents, return nil.
+	assert.Nil(t, lookupPath(document, "missing"))
+	assert.Nil(t, lookupPath(document, "stats.missing"))
+	assert.Nil(t, lookupPath(document, "views.missing"))
+}
+
+func TestRawValue(t *testing.T) {
+
+	document, err := bson.Marshal(bson.M{"_id": "abc", "count": int64(5)})
+	require.NoError(t, err)
+
+	raw := bson.Raw(document)
+
+	assert.True(t, raw.Lookup("_id").Equal(rawValue("abc")))
+	assert.True(t, raw.Lookup("count").Equal(rawValue(int64(5))))
+
+	// Values must match in type as well as content
+	assert.False(t, raw.Lookup("count").Equal(rawValue(5)))
+	assert.False(t, raw.Lookup("_id").Equal(rawValue(make(chan int))))
+}